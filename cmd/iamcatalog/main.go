@@ -0,0 +1,241 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+// Command iamcatalog refreshes the action catalog bundled with the provider from the IAM service
+// authorization reference (https://servicereference.us-east-1.amazonaws.com/).
+//
+// The input is the URL of the reference, whose index and service documents are downloaded, a directory
+// holding one JSON document per service, as served by the reference, or a single file holding one such
+// document or a list of them:
+//
+//	go run ./cmd/iamcatalog -in https://servicereference.us-east-1.amazonaws.com/ -out internal/aws_cloud/iam_actions.json
+//
+// or make actions-catalog. Services read from the reference are marked complete, which lets the provider
+// expand and compact their wildcards; pass -complete=false for documents that list only some actions.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+)
+
+// referenceService is the part of a service document of the authorization reference the catalog uses.
+type referenceService struct {
+	Name    string `json:"Name"`
+	Actions []struct {
+		Name        string `json:"Name"`
+		Annotations struct {
+			Properties struct {
+				IsList                 bool `json:"IsList"`
+				IsPermissionManagement bool `json:"IsPermissionManagement"`
+				IsTaggingOnly          bool `json:"IsTaggingOnly"`
+				IsWrite                bool `json:"IsWrite"`
+			} `json:"Properties"`
+		} `json:"Annotations"`
+		Resources []struct {
+			Name string `json:"Name"`
+		} `json:"Resources"`
+	} `json:"Actions"`
+}
+
+func main() {
+	in := flag.String("in", "", "URL of the service reference, or directory or file with downloaded service reference documents")
+	out := flag.String("out", "iam_actions.json", "catalog file to write")
+	complete := flag.Bool("complete", true, "mark the services as listing every one of their actions")
+	flag.Parse()
+
+	if *in == "" {
+		log.Fatal("-in is required")
+	}
+
+	var services []referenceService
+	var err error
+	if strings.HasPrefix(*in, "https://") || strings.HasPrefix(*in, "http://") {
+		services, err = downloadReference(*in)
+	} else {
+		services, err = readReference(*in)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	catalog := buildCatalog(services)
+
+	names := make([]string, 0, len(catalog))
+	for name := range catalog {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	completed := []string{}
+	if *complete {
+		completed = names
+	}
+	completeJSON, err := json.Marshal(completed)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "{\n\"complete\":%s,\n\"services\":{\n", completeJSON)
+
+	// one service per line keeps refreshes reviewable.
+	for i, name := range names {
+		actions, err := json.Marshal(catalog[name])
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintf(&buf, "%q:%s", name, actions)
+		if i < len(names)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n}\n")
+
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+
+	count := 0
+	for _, actions := range catalog {
+		count += len(actions)
+	}
+	log.Printf("wrote %d actions of %d services to %s", count, len(catalog), *out)
+}
+
+// downloadReference fetches the index of the reference, which lists the URL of every service document,
+// then each service document.
+func downloadReference(index string) ([]referenceService, error) {
+	client := &http.Client{Timeout: time.Minute}
+
+	var entries []struct {
+		Service string `json:"service"`
+		URL     string `json:"url"`
+	}
+	if err := getJSON(client, index, &entries); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s lists no services", index)
+	}
+
+	services := make([]referenceService, 0, len(entries))
+	for _, entry := range entries {
+		var service referenceService
+		if err := getJSON(client, entry.URL, &service); err != nil {
+			return nil, fmt.Errorf("service %s: %w", entry.Service, err)
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+func getJSON(client *http.Client, url string, target any) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(content, target); err != nil {
+		return fmt.Errorf("%s: %w", url, err)
+	}
+	return nil
+}
+
+func readReference(path string) ([]referenceService, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var services []referenceService
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		trimmed := bytes.TrimSpace(content)
+		if len(trimmed) > 0 && trimmed[0] == '[' {
+			var list []referenceService
+			if err := json.Unmarshal(trimmed, &list); err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			services = append(services, list...)
+			continue
+		}
+
+		var service referenceService
+		if err := json.Unmarshal(trimmed, &service); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		// the reference index lists services without actions, skip it.
+		if service.Name != "" {
+			services = append(services, service)
+		}
+	}
+
+	return services, nil
+}
+
+func buildCatalog(services []referenceService) awscloud.ActionCatalog {
+	catalog := make(awscloud.ActionCatalog, len(services))
+	for _, service := range services {
+		prefix := strings.ToLower(service.Name)
+		if catalog[prefix] == nil {
+			catalog[prefix] = make(map[string]awscloud.ActionInfo, len(service.Actions))
+		}
+
+		for _, action := range service.Actions {
+			properties := action.Annotations.Properties
+
+			info := awscloud.ActionInfo{AccessLevel: awscloud.AccessLevelRead}
+			switch {
+			case properties.IsPermissionManagement:
+				info.AccessLevel = awscloud.AccessLevelPermissionsManagement
+			case properties.IsTaggingOnly:
+				info.AccessLevel = awscloud.AccessLevelTagging
+			case properties.IsWrite:
+				info.AccessLevel = awscloud.AccessLevelWrite
+			case properties.IsList:
+				info.AccessLevel = awscloud.AccessLevelList
+			}
+
+			for _, resource := range action.Resources {
+				info.ResourceTypes = append(info.ResourceTypes, resource.Name)
+			}
+
+			catalog[prefix][action.Name] = info
+		}
+	}
+	return catalog
+}
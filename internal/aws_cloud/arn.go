@@ -0,0 +1,185 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ARN is an Amazon Resource Name split into its fields. The resource is split at its first / or :
+// into ResourceType and ResourceID, except for services whose resources have no type.
+type ARN struct {
+	Partition    string
+	Service      string
+	Region       string
+	Account      string
+	ResourceType string
+	ResourceID   string
+	// separator between ResourceType and ResourceID, kept so String gives back the parsed ARN.
+	separator string
+}
+
+// arnServiceRule describes the ARN shape a service uses.
+type arnServiceRule struct {
+	// global services have no region.
+	global bool
+	// noAccount services have no account.
+	noAccount bool
+	// typeless services have no resource type, the whole resource is the ID.
+	typeless bool
+	// separator between resource type and ID when building, defaults to /.
+	separator string
+	// types lists the valid resource types, any when empty.
+	types []string
+}
+
+var arnServiceRules = map[string]arnServiceRule{
+	"cloudfront":     {global: true},
+	"dynamodb":       {},
+	"ecr":            {types: []string{"repository"}},
+	"es":             {types: []string{"domain"}},
+	"events":         {},
+	"iam":            {global: true},
+	"kms":            {types: []string{"key", "alias"}},
+	"lambda":         {separator: ":", types: []string{"function", "layer", "event-source-mapping", "code-signing-config"}},
+	"logs":           {separator: ":"},
+	"rds":            {separator: ":"},
+	"route53":        {global: true, noAccount: true},
+	"s3":             {typeless: true},
+	"secretsmanager": {separator: ":", types: []string{"secret"}},
+	"sns":            {typeless: true},
+	"sqs":            {typeless: true},
+	"ssm":            {},
+	"states":         {separator: ":"},
+	"sts":            {global: true},
+}
+
+var (
+	arnPartitionPattern = regexp.MustCompile(`^aws(-[a-z]+)*$`)
+	arnRegionPattern    = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+	arnAccountPattern   = regexp.MustCompile(`^(\d{12}|aws)$`)
+)
+
+// ParseARN splits an ARN into its fields.
+func ParseARN(value string) (ARN, error) {
+	parts := strings.SplitN(value, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" {
+		return ARN{}, fmt.Errorf("%q is not an ARN, expected arn:partition:service:region:account:resource", value)
+	}
+	if parts[1] == "" || parts[2] == "" || parts[5] == "" {
+		return ARN{}, fmt.Errorf("%q is not an ARN, partition, service and resource cannot be empty", value)
+	}
+
+	parsed := ARN{Partition: parts[1], Service: parts[2], Region: parts[3], Account: parts[4]}
+
+	resource := parts[5]
+	i := strings.IndexAny(resource, "/:")
+	if arnServiceRules[parsed.Service].typeless || i <= 0 {
+		parsed.ResourceID = resource
+		return parsed, nil
+	}
+
+	parsed.ResourceType, parsed.separator, parsed.ResourceID = resource[:i], resource[i:i+1], resource[i+1:]
+	return parsed, nil
+}
+
+// Resource returns the resource field of the ARN.
+func (a ARN) Resource() string {
+	if a.ResourceType == "" {
+		return a.ResourceID
+	}
+
+	separator := a.separator
+	if separator == "" {
+		separator = arnServiceRules[a.Service].separator
+	}
+	if separator == "" {
+		separator = "/"
+	}
+	return a.ResourceType + separator + a.ResourceID
+}
+
+func (a ARN) String() string {
+	return strings.Join([]string{"arn", a.Partition, a.Service, a.Region, a.Account, a.Resource()}, ":")
+}
+
+// Validate checks the fields of the ARN, including the region, account and resource type rules of known services.
+func (a ARN) Validate() error {
+	if !arnPartitionPattern.MatchString(a.Partition) {
+		return fmt.Errorf("invalid partition %q", a.Partition)
+	}
+	if a.Service == "" {
+		return fmt.Errorf("service cannot be empty")
+	}
+	if a.Region != "" && !arnRegionPattern.MatchString(a.Region) {
+		return fmt.Errorf("invalid region %q", a.Region)
+	}
+	if a.Account != "" && !arnAccountPattern.MatchString(a.Account) {
+		return fmt.Errorf("invalid account %q, expected 12 digits", a.Account)
+	}
+	if a.ResourceID == "" {
+		return fmt.Errorf("resource_id cannot be empty")
+	}
+
+	rule, known := arnServiceRules[a.Service]
+	if !known {
+		return nil
+	}
+
+	switch {
+	case rule.global && a.Region != "":
+		return fmt.Errorf("%s ARNs have no region, got %q", a.Service, a.Region)
+	case rule.noAccount && a.Account != "":
+		return fmt.Errorf("%s ARNs have no account, got %q", a.Service, a.Account)
+	case rule.typeless && a.ResourceType != "":
+		return fmt.Errorf("%s ARNs have no resource type, got %q", a.Service, a.ResourceType)
+	case len(rule.types) > 0 && !slices.Contains(rule.types, a.ResourceType):
+		return fmt.Errorf("invalid %s resource type %q, expected one of %s", a.Service, a.ResourceType, strings.Join(rule.types, ", "))
+	}
+
+	// s3 buckets and objects are the only typeless resources without region and account.
+	if !rule.global && !(a.Service == "s3" && a.Region == "" && a.Account == "") {
+		if a.Region == "" {
+			return fmt.Errorf("%s ARNs need a region", a.Service)
+		}
+		if a.Account == "" && !rule.noAccount {
+			return fmt.Errorf("%s ARNs need an account", a.Service)
+		}
+	}
+
+	return nil
+}
+
+// MatchARN reports whether the ARN matches the pattern the way ArnLike does: each of the six
+// colon separated fields is matched on its own, so wildcards before the resource do not span fields.
+func MatchARN(pattern, value string) bool {
+	patternParts := strings.SplitN(pattern, ":", 6)
+	if len(patternParts) != 6 {
+		return MatchWildcard(pattern, value, false)
+	}
+
+	valueParts := strings.SplitN(value, ":", 6)
+	if len(valueParts) != 6 {
+		return false
+	}
+
+	for i := range patternParts {
+		if !MatchWildcard(patternParts[i], valueParts[i], false) {
+			return false
+		}
+	}
+	return true
+}
+
+// arnAccount returns the account field of an ARN, or an empty string.
+func arnAccount(value string) string {
+	parsed, err := ParseARN(value)
+	if err != nil {
+		return ""
+	}
+	return parsed.Account
+}
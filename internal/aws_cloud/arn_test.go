@@ -0,0 +1,85 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import "testing"
+
+func TestParseARN(t *testing.T) {
+	cases := []struct {
+		arn                    string
+		resourceType, resource string
+	}{
+		{"arn:aws:iam::123456789012:role/service/deploy", "role", "service/deploy"},
+		{"arn:aws:lambda:us-east-1:123456789012:function:app:live", "function", "app:live"},
+		{"arn:aws:s3:::bucket/path/key.txt", "", "bucket/path/key.txt"},
+		{"arn:aws:sns:us-east-1:123456789012:topic:4b1f2c3d", "", "topic:4b1f2c3d"},
+		{"arn:aws:apigateway:us-east-1::/restapis/abc", "", "/restapis/abc"},
+	}
+
+	for _, c := range cases {
+		parsed, err := ParseARN(c.arn)
+		if err != nil {
+			t.Fatalf("%s: %v", c.arn, err)
+		}
+		if parsed.ResourceType != c.resourceType || parsed.ResourceID != c.resource {
+			t.Errorf("%s: got type %q id %q", c.arn, parsed.ResourceType, parsed.ResourceID)
+		}
+		if parsed.String() != c.arn {
+			t.Errorf("%s: round trip gave %s", c.arn, parsed.String())
+		}
+	}
+
+	if _, err := ParseARN("arn:aws:s3"); err == nil {
+		t.Error("expected an error for a truncated ARN")
+	}
+}
+
+func TestValidateARN(t *testing.T) {
+	valid := []ARN{
+		{Partition: "aws", Service: "iam", Account: "123456789012", ResourceType: "role", ResourceID: "deploy"},
+		{Partition: "aws", Service: "s3", ResourceID: "bucket"},
+		{Partition: "aws-cn", Service: "lambda", Region: "cn-north-1", Account: "123456789012", ResourceType: "function", ResourceID: "app"},
+	}
+	for _, a := range valid {
+		if err := a.Validate(); err != nil {
+			t.Errorf("%s: %v", a, err)
+		}
+	}
+
+	invalid := []ARN{
+		{Partition: "aws", Service: "iam", Region: "us-east-1", Account: "123456789012", ResourceType: "role", ResourceID: "deploy"},
+		{Partition: "aws", Service: "sqs", Account: "123456789012", ResourceID: "queue"},
+		{Partition: "aws", Service: "kms", Region: "us-east-1", Account: "123456789012", ResourceType: "keys", ResourceID: "abc"},
+		{Partition: "aws", Service: "dynamodb", Region: "us-east-1", Account: "1234", ResourceType: "table", ResourceID: "t"},
+	}
+	for _, a := range invalid {
+		if err := a.Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", a)
+		}
+	}
+
+	if got := (ARN{Partition: "aws", Service: "lambda", Region: "us-east-1", Account: "123456789012", ResourceType: "function", ResourceID: "app"}).String(); got != "arn:aws:lambda:us-east-1:123456789012:function:app" {
+		t.Errorf("built %s", got)
+	}
+}
+
+func TestMatchARN(t *testing.T) {
+	cases := []struct {
+		pattern, arn string
+		want         bool
+	}{
+		{"arn:aws:iam::*:role/deploy-*", "arn:aws:iam::123456789012:role/deploy-prod", true},
+		{"arn:aws:s3:::bucket/*", "arn:aws:s3:::bucket/a/b:c", true},
+		{"arn:aws:*:us-east-1", "arn:aws:sqs:us-east-1:123456789012:queue", false},
+		{"arn:aws:sqs:*:123456789012:*", "arn:aws:sqs:us-east-1:123456789012:queue", true},
+		{"arn:aws:sqs:us-*:123456789012:q?", "arn:aws:sqs:eu-west-1:123456789012:q1", false},
+		{"*", "arn:aws:sqs:us-east-1:123456789012:queue", true},
+	}
+
+	for _, c := range cases {
+		if got := MatchARN(c.pattern, c.arn); got != c.want {
+			t.Errorf("MatchARN(%q, %q) = %v, want %v", c.pattern, c.arn, got, c.want)
+		}
+	}
+}
@@ -5,32 +5,487 @@ package awscloud
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
 	"github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore"
+	kvstypes "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore/types"
 )
 
+const (
+	// CloudFront allows up to 3000 file paths and 15 wildcard paths in progress per distribution.
+	MaxInvalidationPaths     = 3000
+	MaxInvalidationWildcards = 15
+
+	// how long to keep waiting for invalidation capacity before giving up.
+	DefaultInvalidationMaxWait = 15 * time.Minute
+)
+
+// InvalidationOptions controls how paths are normalized and batched before invalidation.
+type InvalidationOptions struct {
+	// collapse sibling paths into a `dir/*` wildcard when a directory has more than this many entries, 0 disables it.
+	WildcardThreshold int
+	MaxPaths          int
+	MaxWildcards      int
+	MaxWait           time.Duration
+}
+
+func (o *InvalidationOptions) setDefaults() {
+	if o.MaxPaths <= 0 {
+		o.MaxPaths = MaxInvalidationPaths
+	}
+	if o.MaxWildcards <= 0 {
+		o.MaxWildcards = MaxInvalidationWildcards
+	}
+	if o.MaxWait <= 0 {
+		o.MaxWait = DefaultInvalidationMaxWait
+	}
+}
+
 // given a distribution ID and paths, invalidate the cache.
-func InvalidateCache(cfg aws.Config, distributionID string, paths []string) (*cloudfront.CreateInvalidationOutput, error) {
+func InvalidateCache(cfg aws.Config, distributionID string, paths []string) ([]*cloudfront.CreateInvalidationOutput, error) {
+	return InvalidateCacheWithOptions(context.TODO(), cfg, distributionID, paths, InvalidationOptions{})
+}
+
+// InvalidateCacheWithOptions normalizes the paths, splits them into batches that fit CloudFront limits
+// and creates one invalidation per batch, waiting for capacity when too many invalidations are in progress.
+func InvalidateCacheWithOptions(ctx context.Context, cfg aws.Config, distributionID string, paths []string, opts InvalidationOptions) ([]*cloudfront.CreateInvalidationOutput, error) {
+	opts.setDefaults()
 
 	svc := cloudfront.NewFromConfig(cfg)
 
-	// create the input
-	input := &cloudfront.CreateInvalidationInput{
-		DistributionId: &distributionID,
-		InvalidationBatch: &types.InvalidationBatch{
-			CallerReference: aws.String(time.Now().Format(time.RFC3339)),
-			Paths: &types.Paths{
-				Quantity: aws.Int32(int32(len(paths))),
-				Items:    paths,
+	batches := BatchPaths(NormalizePaths(paths, opts.WildcardThreshold), opts.MaxPaths, opts.MaxWildcards)
+
+	var results []*cloudfront.CreateInvalidationOutput
+	for i, batch := range batches {
+		// create the input
+		input := &cloudfront.CreateInvalidationInput{
+			DistributionId: &distributionID,
+			InvalidationBatch: &types.InvalidationBatch{
+				CallerReference: aws.String(newCallerReference(i)),
+				Paths: &types.Paths{
+					Quantity: aws.Int32(int32(len(batch))),
+					Items:    batch,
+				},
 			},
-		},
+		}
+
+		// create the invalidation.
+		res, err := createInvalidationWithWait(ctx, svc, input, opts.MaxWait)
+		if err != nil {
+			return results, fmt.Errorf("failed to invalidate batch %d of %d for %s: %w", i+1, len(batches), distributionID, err)
+		}
+
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+// InvalidateDistributions invalidates the same paths on every distribution concurrently.
+// Results are keyed by distribution ID; failures of individual distributions are joined into one error.
+func InvalidateDistributions(ctx context.Context, cfg aws.Config, distributionIDs []string, paths []string, opts InvalidationOptions) (map[string][]*cloudfront.CreateInvalidationOutput, error) {
+	results := make(map[string][]*cloudfront.CreateInvalidationOutput, len(distributionIDs))
+	var errs []error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, id := range distributionIDs {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+
+			res, err := InvalidateCacheWithOptions(ctx, cfg, id, paths, opts)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(res) > 0 {
+				results[id] = res
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}(id)
+	}
+
+	wg.Wait()
+
+	return results, errors.Join(errs...)
+}
+
+// newCallerReference returns a reference that stays unique for invalidations started in the same second.
+func newCallerReference(batch int) string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Sprintf("%d-%d", time.Now().UnixNano(), batch)
+	}
+
+	return fmt.Sprintf("%d-%s-%d", time.Now().UnixNano(), hex.EncodeToString(suffix), batch)
+}
+
+// createInvalidationWithWait retries the invalidation with backoff while CloudFront reports TooManyInvalidationsInProgress.
+func createInvalidationWithWait(ctx context.Context, svc *cloudfront.Client, input *cloudfront.CreateInvalidationInput, maxWait time.Duration) (*cloudfront.CreateInvalidationOutput, error) {
+	deadline := time.Now().Add(maxWait)
+	delay := 5 * time.Second
+
+	for {
+		res, err := svc.CreateInvalidation(ctx, input)
+
+		var tooMany *types.TooManyInvalidationsInProgress
+		if err == nil || !errors.As(err, &tooMany) {
+			return res, err
+		}
+
+		if time.Now().Add(delay).After(deadline) {
+			return nil, fmt.Errorf("gave up waiting for invalidation capacity after %s: %w", maxWait, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		if delay < time.Minute {
+			delay *= 2
+		}
+	}
+}
+
+// NormalizePaths adds leading slashes, URL-encodes, dedupes and sorts the paths.
+// Paths already covered by a wildcard are dropped, and when threshold is above 0,
+// directories with more than threshold entries are collapsed into a `dir/*` wildcard.
+func NormalizePaths(paths []string, threshold int) []string {
+	unique := make(map[string]bool)
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		unique[encodePath(p)] = true
+	}
+
+	for {
+		pruneCoveredPaths(unique)
+
+		if threshold <= 0 || !collapseSiblings(unique, threshold) {
+			break
+		}
+	}
+
+	normalized := make([]string, 0, len(unique))
+	for p := range unique {
+		normalized = append(normalized, p)
+	}
+	sort.Strings(normalized)
+
+	return normalized
+}
+
+// encodePath adds the leading slash and URL-encodes each segment, keeping a trailing `*` as a wildcard.
+func encodePath(p string) string {
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		wildcard := strings.HasSuffix(segment, "*")
+		segment = strings.TrimSuffix(segment, "*")
+
+		// decode first so already encoded paths are not encoded twice.
+		if decoded, err := url.PathUnescape(segment); err == nil {
+			segment = decoded
+		}
+		segment = url.PathEscape(segment)
+
+		if wildcard {
+			segment += "*"
+		}
+		segments[i] = segment
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// pruneCoveredPaths removes every path that is already matched by another wildcard path.
+func pruneCoveredPaths(paths map[string]bool) {
+	var prefixes []string
+	for p := range paths {
+		if strings.HasSuffix(p, "*") {
+			prefixes = append(prefixes, strings.TrimSuffix(p, "*"))
+		}
+	}
+
+	for p := range paths {
+		for _, prefix := range prefixes {
+			if p != prefix+"*" && strings.HasPrefix(p, prefix) {
+				delete(paths, p)
+				break
+			}
+		}
+	}
+}
+
+// collapseSiblings replaces the children of any directory holding more than threshold entries with `dir/*`.
+// It reports whether anything was collapsed.
+func collapseSiblings(paths map[string]bool, threshold int) bool {
+	children := make(map[string][]string)
+	for p := range paths {
+		// the root wildcard already covers everything.
+		if p == "/*" {
+			continue
+		}
+
+		dir := path.Dir(strings.TrimSuffix(p, "*"))
+		if strings.HasSuffix(p, "/*") {
+			dir = path.Dir(strings.TrimSuffix(p, "/*"))
+		}
+		children[dir] = append(children[dir], p)
+	}
+
+	collapsed := false
+	for dir, entries := range children {
+		wildcard := strings.TrimSuffix(dir, "/") + "/*"
+		if len(entries) <= threshold {
+			continue
+		}
+
+		for _, p := range entries {
+			delete(paths, p)
+		}
+		paths[wildcard] = true
+		collapsed = true
+	}
+
+	return collapsed
+}
+
+// BatchPaths splits the paths into batches holding at most maxPaths paths and maxWildcards wildcard paths each.
+func BatchPaths(paths []string, maxPaths, maxWildcards int) [][]string {
+	var batches [][]string
+	var batch []string
+	wildcards := 0
+
+	for _, p := range paths {
+		isWildcard := strings.HasSuffix(p, "*")
+
+		if len(batch) >= maxPaths || (isWildcard && wildcards >= maxWildcards) {
+			batches = append(batches, batch)
+			batch = nil
+			wildcards = 0
+		}
+
+		batch = append(batch, p)
+		if isWildcard {
+			wildcards++
+		}
+	}
+
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+// ErrInvalidationNotFound is returned by GetInvalidation when the invalidation or its distribution no longer exists.
+var ErrInvalidationNotFound = errors.New("invalidation not found")
+
+// GetInvalidation returns the invalidation with the given ID on the distribution.
+func GetInvalidation(ctx context.Context, cfg aws.Config, distributionID string, invalidationID string) (*cloudfront.GetInvalidationOutput, error) {
+	svc := cloudfront.NewFromConfig(cfg)
+
+	res, err := svc.GetInvalidation(ctx, &cloudfront.GetInvalidationInput{
+		DistributionId: &distributionID,
+		Id:             &invalidationID,
+	})
+	var noDistribution *types.NoSuchDistribution
+	var noInvalidation *types.NoSuchInvalidation
+	if errors.As(err, &noDistribution) || errors.As(err, &noInvalidation) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidationNotFound, err)
+	}
+	return res, err
+}
+
+// distributionAPI is the part of the CloudFront client FindDistributions uses.
+type distributionAPI interface {
+	cloudfront.ListDistributionsAPIClient
+	ListTagsForResource(ctx context.Context, params *cloudfront.ListTagsForResourceInput, optFns ...func(*cloudfront.Options)) (*cloudfront.ListTagsForResourceOutput, error)
+}
+
+// DistributionFilter selects distributions by ID, alternate domain name (CNAME) or tags.
+type DistributionFilter struct {
+	IDs     []string
+	Aliases []string
+	Tags    map[string]string
+}
+
+// FindDistributions pages through ListDistributions and returns the distributions matching the filter.
+// A distribution matches when its ID is listed, or when it carries one of the aliases and all of the tags.
+func FindDistributions(ctx context.Context, cfg aws.Config, filter DistributionFilter) ([]types.DistributionSummary, error) {
+	return findDistributions(ctx, cloudfront.NewFromConfig(cfg), filter)
+}
+
+func findDistributions(ctx context.Context, svc distributionAPI, filter DistributionFilter) ([]types.DistributionSummary, error) {
+	ids := make(map[string]bool, len(filter.IDs))
+	for _, id := range filter.IDs {
+		ids[id] = true
 	}
+	lookup := len(filter.Aliases) > 0 || len(filter.Tags) > 0
 
-	// create the invalidation.
-	return svc.CreateInvalidation(context.TODO(), input)
+	var matches []types.DistributionSummary
+	paginator := cloudfront.NewListDistributionsPaginator(svc, &cloudfront.ListDistributionsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list distributions: %w", err)
+		}
+		if page.DistributionList == nil {
+			continue
+		}
+
+		for _, dist := range page.DistributionList.Items {
+			if ids[aws.ToString(dist.Id)] {
+				matches = append(matches, dist)
+				continue
+			}
+			if !lookup || !hasAnyAlias(dist, filter.Aliases) {
+				continue
+			}
+
+			ok, err := hasAllTags(ctx, svc, dist, filter.Tags)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				matches = append(matches, dist)
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+// ResolveDistributionIDs returns the sorted, unique IDs of the distributions selected by the filter.
+// Listed IDs are returned as is, so only alias and tag lookups need ListDistributions.
+func ResolveDistributionIDs(ctx context.Context, cfg aws.Config, filter DistributionFilter) ([]string, error) {
+	unique := make(map[string]bool)
+	for _, id := range filter.IDs {
+		unique[id] = true
+	}
+
+	if len(filter.Aliases) > 0 || len(filter.Tags) > 0 {
+		dists, err := FindDistributions(ctx, cfg, DistributionFilter{Aliases: filter.Aliases, Tags: filter.Tags})
+		if err != nil {
+			return nil, err
+		}
+		if len(dists) == 0 {
+			return nil, fmt.Errorf("no distribution found for aliases %v and tags %v", filter.Aliases, filter.Tags)
+		}
+		for _, dist := range dists {
+			unique[aws.ToString(dist.Id)] = true
+		}
+	}
+
+	ids := make([]string, 0, len(unique))
+	for id := range unique {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// LookupDistribution returns the single distribution selected by the filter.
+// The first listed ID wins; otherwise the aliases and tags must match exactly one distribution.
+func LookupDistribution(ctx context.Context, cfg aws.Config, filter DistributionFilter) (*types.Distribution, error) {
+	id := ""
+	if len(filter.IDs) > 0 {
+		id = filter.IDs[0]
+	} else {
+		dists, err := FindDistributions(ctx, cfg, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		switch len(dists) {
+		case 0:
+			return nil, fmt.Errorf("no distribution found for aliases %v and tags %v", filter.Aliases, filter.Tags)
+		case 1:
+			id = aws.ToString(dists[0].Id)
+		default:
+			found := make([]string, 0, len(dists))
+			for _, dist := range dists {
+				found = append(found, aws.ToString(dist.Id))
+			}
+			return nil, fmt.Errorf("%d distributions found for aliases %v and tags %v: %v", len(dists), filter.Aliases, filter.Tags, found)
+		}
+	}
+
+	svc := cloudfront.NewFromConfig(cfg)
+	res, err := svc.GetDistribution(ctx, &cloudfront.GetDistributionInput{Id: &id})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get distribution %s: %w", id, err)
+	}
+
+	return res.Distribution, nil
+}
+
+func hasAnyAlias(dist types.DistributionSummary, aliases []string) bool {
+	if len(aliases) == 0 {
+		return true
+	}
+	if dist.Aliases == nil {
+		return false
+	}
+
+	for _, alias := range aliases {
+		for _, item := range dist.Aliases.Items {
+			if strings.EqualFold(alias, item) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func hasAllTags(ctx context.Context, svc distributionAPI, dist types.DistributionSummary, tags map[string]string) (bool, error) {
+	if len(tags) == 0 {
+		return true, nil
+	}
+
+	res, err := svc.ListTagsForResource(ctx, &cloudfront.ListTagsForResourceInput{Resource: dist.ARN})
+	if err != nil {
+		return false, fmt.Errorf("failed to list tags for distribution %s: %w", aws.ToString(dist.Id), err)
+	}
+
+	current := make(map[string]string)
+	if res.Tags != nil {
+		for _, tag := range res.Tags.Items {
+			current[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+
+	for k, v := range tags {
+		if value, ok := current[k]; !ok || value != v {
+			return false, nil
+		}
+	}
+
+	return true, nil
 }
 
 // get the dsitribtion by ID.
@@ -46,3 +501,181 @@ func GetDistribution(cfg aws.Config, distributionID string) (*cloudfront.GetDist
 	// get the distribution.
 	return svc.GetDistribution(context.TODO(), input)
 }
+
+// UpdateKeys accepts at most 50 puts and deletes per call.
+const maxKVSKeysPerUpdate = 50
+
+// kvsAPI is the part of the KeyValueStore client the sync uses.
+type kvsAPI interface {
+	cloudfrontkeyvaluestore.ListKeysAPIClient
+	DescribeKeyValueStore(ctx context.Context, params *cloudfrontkeyvaluestore.DescribeKeyValueStoreInput, optFns ...func(*cloudfrontkeyvaluestore.Options)) (*cloudfrontkeyvaluestore.DescribeKeyValueStoreOutput, error)
+	UpdateKeys(ctx context.Context, params *cloudfrontkeyvaluestore.UpdateKeysInput, optFns ...func(*cloudfrontkeyvaluestore.Options)) (*cloudfrontkeyvaluestore.UpdateKeysOutput, error)
+}
+
+// ListKVSKeys returns every key and value of the KeyValueStore.
+func ListKVSKeys(ctx context.Context, cfg aws.Config, kvsARN string) (map[string]string, error) {
+	return listKVSKeys(ctx, cloudfrontkeyvaluestore.NewFromConfig(cfg), kvsARN)
+}
+
+func listKVSKeys(ctx context.Context, svc kvsAPI, kvsARN string) (map[string]string, error) {
+	items := make(map[string]string)
+	paginator := cloudfrontkeyvaluestore.NewListKeysPaginator(svc, &cloudfrontkeyvaluestore.ListKeysInput{
+		KvsARN:     &kvsARN,
+		MaxResults: aws.Int32(maxKVSKeysPerUpdate),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list keys of %s: %w", kvsARN, err)
+		}
+
+		for _, item := range page.Items {
+			items[aws.ToString(item.Key)] = aws.ToString(item.Value)
+		}
+	}
+
+	return items, nil
+}
+
+// snapshotKVS returns the store ETag and then its keys. The ETag is read first so that UpdateKeys
+// rejects the changes when the store was modified after it, including while the keys were listed.
+func snapshotKVS(ctx context.Context, svc kvsAPI, kvsARN string) (*string, map[string]string, error) {
+	store, err := svc.DescribeKeyValueStore(ctx, &cloudfrontkeyvaluestore.DescribeKeyValueStoreInput{KvsARN: &kvsARN})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to describe %s: %w", kvsARN, err)
+	}
+
+	current, err := listKVSKeys(ctx, svc, kvsARN)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.ETag, current, nil
+}
+
+// KVSChanges holds the keys to put and delete to bring a KeyValueStore to the desired state.
+type KVSChanges struct {
+	Puts    map[string]string
+	Deletes []string
+}
+
+// DiffKVS compares the current and desired keys. Keys missing from desired are only
+// deleted when deletable reports true for them.
+func DiffKVS(current, desired map[string]string, deletable func(key string) bool) KVSChanges {
+	changes := KVSChanges{Puts: make(map[string]string)}
+
+	for k, v := range desired {
+		if value, ok := current[k]; !ok || value != v {
+			changes.Puts[k] = v
+		}
+	}
+
+	for k := range current {
+		if _, ok := desired[k]; !ok && deletable(k) {
+			changes.Deletes = append(changes.Deletes, k)
+		}
+	}
+	sort.Strings(changes.Deletes)
+
+	return changes
+}
+
+// SyncKVS brings the KeyValueStore to the desired keys with UpdateKeys batches guarded by the store ETag.
+// Keys not in desired are deleted when prune is set, otherwise only the previously managed ones are.
+// The whole diff is recomputed and retried when another writer changes the store concurrently.
+func SyncKVS(ctx context.Context, cfg aws.Config, kvsARN string, desired map[string]string, managed []string, prune bool) (string, error) {
+	return syncKVS(ctx, cloudfrontkeyvaluestore.NewFromConfig(cfg), kvsARN, desired, managed, prune)
+}
+
+func syncKVS(ctx context.Context, svc kvsAPI, kvsARN string, desired map[string]string, managed []string, prune bool) (string, error) {
+	owned := make(map[string]bool, len(managed))
+	for _, k := range managed {
+		owned[k] = true
+	}
+
+	const maxAttempts = 5
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		var etag *string
+		var current map[string]string
+		etag, current, err = snapshotKVS(ctx, svc, kvsARN)
+		if err != nil {
+			return "", err
+		}
+
+		changes := DiffKVS(current, desired, func(key string) bool {
+			return prune || owned[key]
+		})
+
+		var updated string
+		updated, err = updateKVS(ctx, svc, kvsARN, etag, changes)
+
+		var conflict *kvstypes.ConflictException
+		if err == nil || !errors.As(err, &conflict) {
+			return updated, err
+		}
+	}
+
+	return "", fmt.Errorf("gave up syncing %s after %d concurrent modifications: %w", kvsARN, maxAttempts, err)
+}
+
+// DeleteKVSKeys removes the keys from the KeyValueStore, ignoring keys that no longer exist.
+func DeleteKVSKeys(ctx context.Context, cfg aws.Config, kvsARN string, keys []string) error {
+	svc := cloudfrontkeyvaluestore.NewFromConfig(cfg)
+
+	etag, current, err := snapshotKVS(ctx, svc, kvsARN)
+	if err != nil {
+		return err
+	}
+
+	changes := KVSChanges{}
+	for _, k := range keys {
+		if _, ok := current[k]; ok {
+			changes.Deletes = append(changes.Deletes, k)
+		}
+	}
+
+	_, err = updateKVS(ctx, svc, kvsARN, etag, changes)
+	return err
+}
+
+// updateKVS applies the changes in batches, the first guarded by etag and each next one by the ETag
+// returned by the previous one.
+func updateKVS(ctx context.Context, svc kvsAPI, kvsARN string, etag *string, changes KVSChanges) (string, error) {
+	keys := make([]string, 0, len(changes.Puts))
+	for k := range changes.Puts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var puts []kvstypes.PutKeyRequestListItem
+	for _, k := range keys {
+		puts = append(puts, kvstypes.PutKeyRequestListItem{Key: aws.String(k), Value: aws.String(changes.Puts[k])})
+	}
+
+	var deletes []kvstypes.DeleteKeyRequestListItem
+	for _, k := range changes.Deletes {
+		deletes = append(deletes, kvstypes.DeleteKeyRequestListItem{Key: aws.String(k)})
+	}
+
+	for len(puts) > 0 || len(deletes) > 0 {
+		input := &cloudfrontkeyvaluestore.UpdateKeysInput{
+			KvsARN:  &kvsARN,
+			IfMatch: etag,
+		}
+
+		// fill the batch with puts first, then deletes.
+		n := min(len(puts), maxKVSKeysPerUpdate)
+		input.Puts, puts = puts[:n], puts[n:]
+
+		n = min(len(deletes), maxKVSKeysPerUpdate-len(input.Puts))
+		input.Deletes, deletes = deletes[:n], deletes[n:]
+
+		res, err := svc.UpdateKeys(ctx, input)
+		if err != nil {
+			return "", fmt.Errorf("failed to update keys of %s: %w", kvsARN, err)
+		}
+		etag = res.ETag
+	}
+
+	return aws.ToString(etag), nil
+}
@@ -0,0 +1,176 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SignOptions describes what a CloudFront signature grants.
+// Only Expires is required; setting any other field switches from a canned to a custom policy.
+type SignOptions struct {
+	Expires   time.Time
+	NotBefore *time.Time
+	IPAddress string
+	// Resource may contain wildcards; defaults to the signed URL.
+	Resource string
+}
+
+type signPolicy struct {
+	Statement []signStatement `json:"Statement"`
+}
+
+type signStatement struct {
+	Resource  string                    `json:"Resource"`
+	Condition map[string]map[string]any `json:"Condition"`
+}
+
+// custom reports whether the options need a custom policy rather than a canned one.
+func (o SignOptions) custom(url string) bool {
+	return o.NotBefore != nil || o.IPAddress != "" || (o.Resource != "" && o.Resource != url)
+}
+
+// policy returns the policy document for the url, in the exact form CloudFront rebuilds for canned policies.
+func (o SignOptions) policy(url string) ([]byte, error) {
+	resource := o.Resource
+	if resource == "" {
+		resource = url
+	}
+
+	condition := map[string]map[string]any{
+		"DateLessThan": {"AWS:EpochTime": o.Expires.Unix()},
+	}
+	if o.NotBefore != nil {
+		condition["DateGreaterThan"] = map[string]any{"AWS:EpochTime": o.NotBefore.Unix()}
+	}
+	if o.IPAddress != "" {
+		condition["IpAddress"] = map[string]any{"AWS:SourceIp": o.IPAddress}
+	}
+
+	// urls commonly contain `&`, which must not be HTML escaped.
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(signPolicy{Statement: []signStatement{{Resource: resource, Condition: condition}}}); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// ParseRSAPrivateKey parses a PEM encoded PKCS#1 or PKCS#8 RSA private key.
+func ParseRSAPrivateKey(privateKeyPEM string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private key: %w", err)
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key must be an RSA key, got %T", parsed)
+	}
+
+	return key, nil
+}
+
+// SignedURL returns the url with the Expires or Policy, Signature and Key-Pair-Id query parameters appended.
+func SignedURL(url string, keyPairID string, privateKeyPEM string, opts SignOptions) (string, error) {
+	params, err := signParams(url, keyPairID, privateKeyPEM, opts)
+	if err != nil {
+		return "", err
+	}
+
+	separator := "?"
+	if strings.Contains(url, "?") {
+		separator = "&"
+	}
+
+	var query []string
+	for _, name := range []string{"Expires", "Policy", "Signature", "Key-Pair-Id"} {
+		if value, ok := params[name]; ok {
+			query = append(query, name+"="+value)
+		}
+	}
+
+	return url + separator + strings.Join(query, "&"), nil
+}
+
+// SignedCookies returns the CloudFront-Expires or CloudFront-Policy, CloudFront-Signature and CloudFront-Key-Pair-Id cookie values.
+func SignedCookies(url string, keyPairID string, privateKeyPEM string, opts SignOptions) (map[string]string, error) {
+	params, err := signParams(url, keyPairID, privateKeyPEM, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cookies := make(map[string]string, len(params))
+	for name, value := range params {
+		cookies["CloudFront-"+name] = value
+	}
+
+	return cookies, nil
+}
+
+func signParams(url string, keyPairID string, privateKeyPEM string, opts SignOptions) (map[string]string, error) {
+	if opts.Expires.IsZero() {
+		return nil, fmt.Errorf("an expiry time is required")
+	}
+	if keyPairID == "" {
+		return nil, fmt.Errorf("a key pair ID is required")
+	}
+
+	key, err := ParseRSAPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := opts.policy(url)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build policy: %w", err)
+	}
+
+	// CloudFront only accepts RSA-SHA1 signatures.
+	hash := sha1.Sum(policy)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA1, hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign policy: %w", err)
+	}
+
+	params := map[string]string{
+		"Signature":   cloudFrontBase64(signature),
+		"Key-Pair-Id": keyPairID,
+	}
+
+	if opts.custom(url) {
+		params["Policy"] = cloudFrontBase64(policy)
+	} else {
+		params["Expires"] = fmt.Sprint(opts.Expires.Unix())
+	}
+
+	return params, nil
+}
+
+// cloudFrontBase64 encodes with the URL safe alphabet CloudFront expects.
+func cloudFrontBase64(b []byte) string {
+	return strings.NewReplacer("+", "-", "=", "_", "/", "~").Replace(base64.StdEncoding.EncodeToString(b))
+}
@@ -0,0 +1,115 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testPrivateKey(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func decodeCloudFrontBase64(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := base64.StdEncoding.DecodeString(strings.NewReplacer("-", "+", "_", "=", "~", "/").Replace(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func verifySignature(t *testing.T, key *rsa.PrivateKey, policy string, signature string) {
+	t.Helper()
+
+	hash := sha1.Sum([]byte(policy))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA1, hash[:], decodeCloudFrontBase64(t, signature)); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+}
+
+func TestSignedURLCannedPolicy(t *testing.T) {
+	key, keyPEM := testPrivateKey(t)
+	resource := "https://d111111abcdef8.cloudfront.net/private/file.pdf?a=1&b=2"
+	expires := time.Unix(1767225600, 0)
+
+	signed, err := SignedURL(resource, "K2JCJMDEHXQW5F", keyPEM, SignOptions{Expires: expires})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(signed, resource+"&Expires=1767225600&Signature=") {
+		t.Fatalf("unexpected signed URL %s", signed)
+	}
+
+	query, err := url.ParseQuery(strings.SplitN(signed, "?", 2)[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("Key-Pair-Id") != "K2JCJMDEHXQW5F" {
+		t.Errorf("Key-Pair-Id = %q", query.Get("Key-Pair-Id"))
+	}
+
+	canned := `{"Statement":[{"Resource":"` + resource + `","Condition":{"DateLessThan":{"AWS:EpochTime":1767225600}}}]}`
+	verifySignature(t, key, canned, query.Get("Signature"))
+
+	again, err := SignedURL(resource, "K2JCJMDEHXQW5F", keyPEM, SignOptions{Expires: expires})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != signed {
+		t.Errorf("signing is not deterministic: %s != %s", again, signed)
+	}
+}
+
+func TestSignedCookiesCustomPolicy(t *testing.T) {
+	key, keyPEM := testPrivateKey(t)
+	notBefore := time.Unix(1767139200, 0)
+
+	cookies, err := SignedCookies("https://example.com/videos/a.mp4", "K2JCJMDEHXQW5F", keyPEM, SignOptions{
+		Expires:   time.Unix(1767225600, 0),
+		NotBefore: &notBefore,
+		IPAddress: "192.0.2.0/24",
+		Resource:  "https://example.com/videos/*",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cookies) != 3 || cookies["CloudFront-Key-Pair-Id"] != "K2JCJMDEHXQW5F" {
+		t.Fatalf("unexpected cookies %v", cookies)
+	}
+
+	policy := string(decodeCloudFrontBase64(t, cookies["CloudFront-Policy"]))
+	want := `{"Statement":[{"Resource":"https://example.com/videos/*","Condition":{"DateGreaterThan":{"AWS:EpochTime":1767139200},"DateLessThan":{"AWS:EpochTime":1767225600},"IpAddress":{"AWS:SourceIp":"192.0.2.0/24"}}}]}`
+	if policy != want {
+		t.Errorf("policy = %s, want %s", policy, want)
+	}
+
+	verifySignature(t, key, policy, cookies["CloudFront-Signature"])
+}
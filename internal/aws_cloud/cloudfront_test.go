@@ -0,0 +1,272 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore"
+	kvstypes "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore/types"
+)
+
+func TestNormalizePaths(t *testing.T) {
+	cases := []struct {
+		name      string
+		paths     []string
+		threshold int
+		want      []string
+	}{
+		{
+			name:  "leading slash, encoding and dedupe",
+			paths: []string{"index.html", "/index.html", "/img/my photo.png", "/img/my%20photo.png", " "},
+			want:  []string{"/img/my%20photo.png", "/index.html"},
+		},
+		{
+			name:  "paths covered by a wildcard are dropped",
+			paths: []string{"/assets/app.js", "/assets/*", "/assets/css/site.css", "/about.html"},
+			want:  []string{"/about.html", "/assets/*"},
+		},
+		{
+			name:  "root wildcard covers everything",
+			paths: []string{"/a", "/b/c", "/*"},
+			want:  []string{"/*"},
+		},
+		{
+			name:      "siblings above the threshold are collapsed",
+			paths:     []string{"/docs/a.html", "/docs/b.html", "/docs/c.html", "/index.html"},
+			threshold: 2,
+			want:      []string{"/docs/*", "/index.html"},
+		},
+		{
+			name:      "collapsing cascades to the parent directory",
+			paths:     []string{"/a/x/1", "/a/x/2", "/a/y/1", "/a/y/2", "/a/z"},
+			threshold: 1,
+			want:      []string{"/a/*"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := NormalizePaths(c.paths, c.threshold)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("NormalizePaths(%v, %d) = %v, want %v", c.paths, c.threshold, got, c.want)
+			}
+		})
+	}
+}
+
+func TestBatchPaths(t *testing.T) {
+	var paths []string
+	for i := 0; i < 7; i++ {
+		paths = append(paths, fmt.Sprintf("/f%d", i))
+	}
+	for i := 0; i < 4; i++ {
+		paths = append(paths, fmt.Sprintf("/w%d/*", i))
+	}
+
+	batches := BatchPaths(paths, 5, 2)
+
+	want := [][]string{
+		{"/f0", "/f1", "/f2", "/f3", "/f4"},
+		{"/f5", "/f6", "/w0/*", "/w1/*"},
+		{"/w2/*", "/w3/*"},
+	}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("BatchPaths() = %v, want %v", batches, want)
+	}
+}
+
+func TestDiffKVS(t *testing.T) {
+	current := map[string]string{"same": "1", "changed": "old", "managed": "x", "foreign": "y"}
+	desired := map[string]string{"same": "1", "changed": "new", "added": "z"}
+
+	changes := DiffKVS(current, desired, func(k string) bool { return k == "managed" })
+
+	wantPuts := map[string]string{"changed": "new", "added": "z"}
+	if !reflect.DeepEqual(changes.Puts, wantPuts) {
+		t.Errorf("DiffKVS() puts = %v, want %v", changes.Puts, wantPuts)
+	}
+	if !reflect.DeepEqual(changes.Deletes, []string{"managed"}) {
+		t.Errorf("DiffKVS() deletes = %v, want [managed]", changes.Deletes)
+	}
+}
+
+// fakeKVS is a KeyValueStore whose UpdateKeys enforces IfMatch. onList runs after each listing,
+// to simulate another writer changing the store between the listing and the update.
+type fakeKVS struct {
+	items   map[string]string
+	version int
+	onList  func(f *fakeKVS)
+	ifMatch []string
+}
+
+func (f *fakeKVS) etag() *string { return aws.String(fmt.Sprint("etag-", f.version)) }
+
+func (f *fakeKVS) DescribeKeyValueStore(_ context.Context, _ *cloudfrontkeyvaluestore.DescribeKeyValueStoreInput, _ ...func(*cloudfrontkeyvaluestore.Options)) (*cloudfrontkeyvaluestore.DescribeKeyValueStoreOutput, error) {
+	return &cloudfrontkeyvaluestore.DescribeKeyValueStoreOutput{ETag: f.etag()}, nil
+}
+
+func (f *fakeKVS) ListKeys(_ context.Context, _ *cloudfrontkeyvaluestore.ListKeysInput, _ ...func(*cloudfrontkeyvaluestore.Options)) (*cloudfrontkeyvaluestore.ListKeysOutput, error) {
+	out := &cloudfrontkeyvaluestore.ListKeysOutput{}
+	for k, v := range f.items {
+		out.Items = append(out.Items, kvstypes.ListKeysResponseListItem{Key: aws.String(k), Value: aws.String(v)})
+	}
+	if f.onList != nil {
+		f.onList(f)
+	}
+	return out, nil
+}
+
+func (f *fakeKVS) UpdateKeys(_ context.Context, in *cloudfrontkeyvaluestore.UpdateKeysInput, _ ...func(*cloudfrontkeyvaluestore.Options)) (*cloudfrontkeyvaluestore.UpdateKeysOutput, error) {
+	f.ifMatch = append(f.ifMatch, aws.ToString(in.IfMatch))
+	if aws.ToString(in.IfMatch) != aws.ToString(f.etag()) {
+		return nil, &kvstypes.ConflictException{Message: aws.String("etag mismatch")}
+	}
+
+	for _, item := range in.Puts {
+		f.items[aws.ToString(item.Key)] = aws.ToString(item.Value)
+	}
+	for _, item := range in.Deletes {
+		delete(f.items, aws.ToString(item.Key))
+	}
+	f.version++
+	return &cloudfrontkeyvaluestore.UpdateKeysOutput{ETag: f.etag()}, nil
+}
+
+func TestSyncKVSConcurrentChange(t *testing.T) {
+	kvs := &fakeKVS{items: map[string]string{"a": "0"}}
+	// another writer adds a key right after the first listing.
+	kvs.onList = func(f *fakeKVS) {
+		f.items["other"] = "x"
+		f.version++
+		f.onList = nil
+	}
+
+	etag, err := syncKVS(context.Background(), kvs, "arn", map[string]string{"a": "1"}, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the update based on the stale listing is rejected, the retry sees and prunes the new key.
+	if want := []string{"etag-0", "etag-1"}; !reflect.DeepEqual(kvs.ifMatch, want) {
+		t.Errorf("UpdateKeys IfMatch = %v, want %v", kvs.ifMatch, want)
+	}
+	if want := map[string]string{"a": "1"}; !reflect.DeepEqual(kvs.items, want) {
+		t.Errorf("items = %v, want %v", kvs.items, want)
+	}
+	if etag != "etag-2" {
+		t.Errorf("syncKVS() = %s, want etag-2", etag)
+	}
+}
+
+// fakeDistributionAPI serves distribution pages and tags from memory.
+type fakeDistributionAPI struct {
+	pages [][]types.DistributionSummary
+	tags  map[string]map[string]string
+}
+
+func (f *fakeDistributionAPI) ListDistributions(_ context.Context, in *cloudfront.ListDistributionsInput, _ ...func(*cloudfront.Options)) (*cloudfront.ListDistributionsOutput, error) {
+	page := 0
+	if in.Marker != nil {
+		fmt.Sscan(*in.Marker, &page)
+	}
+
+	list := &types.DistributionList{Items: f.pages[page], IsTruncated: aws.Bool(page+1 < len(f.pages))}
+	if page+1 < len(f.pages) {
+		list.NextMarker = aws.String(fmt.Sprint(page + 1))
+	}
+	return &cloudfront.ListDistributionsOutput{DistributionList: list}, nil
+}
+
+func (f *fakeDistributionAPI) ListTagsForResource(_ context.Context, in *cloudfront.ListTagsForResourceInput, _ ...func(*cloudfront.Options)) (*cloudfront.ListTagsForResourceOutput, error) {
+	tags := &types.Tags{}
+	for k, v := range f.tags[aws.ToString(in.Resource)] {
+		tags.Items = append(tags.Items, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return &cloudfront.ListTagsForResourceOutput{Tags: tags}, nil
+}
+
+func distribution(id string, aliases ...string) types.DistributionSummary {
+	return types.DistributionSummary{Id: aws.String(id), ARN: aws.String("arn:aws:cloudfront::123456789012:distribution/" + id), Aliases: &types.Aliases{Items: aliases}}
+}
+
+func TestFindDistributions(t *testing.T) {
+	api := &fakeDistributionAPI{
+		pages: [][]types.DistributionSummary{
+			{distribution("E1", "www.example.com"), distribution("E2", "api.example.com")},
+			{distribution("E3", "WWW.example.com", "cdn.example.com"), distribution("E4")},
+		},
+		tags: map[string]map[string]string{
+			"arn:aws:cloudfront::123456789012:distribution/E1": {"env": "prod"},
+			"arn:aws:cloudfront::123456789012:distribution/E3": {"env": "dev"},
+			"arn:aws:cloudfront::123456789012:distribution/E4": {"env": "prod"},
+		},
+	}
+
+	cases := []struct {
+		name   string
+		filter DistributionFilter
+		want   []string
+	}{
+		{"ids", DistributionFilter{IDs: []string{"E2", "E4"}}, []string{"E2", "E4"}},
+		{"aliases across pages, case insensitive", DistributionFilter{Aliases: []string{"www.example.com"}}, []string{"E1", "E3"}},
+		{"aliases and tags", DistributionFilter{Aliases: []string{"www.example.com"}, Tags: map[string]string{"env": "prod"}}, []string{"E1"}},
+		{"tags only", DistributionFilter{Tags: map[string]string{"env": "prod"}}, []string{"E1", "E4"}},
+		{"no match", DistributionFilter{Aliases: []string{"other.example.com"}}, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dists, err := findDistributions(context.Background(), api, c.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, dist := range dists {
+				got = append(got, aws.ToString(dist.Id))
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("findDistributions() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestHasAnyAlias(t *testing.T) {
+	dist := distribution("E1", "www.example.com", "cdn.example.com")
+
+	if !hasAnyAlias(dist, nil) {
+		t.Error("no aliases should match every distribution")
+	}
+	if !hasAnyAlias(dist, []string{"other.example.com", "CDN.example.com"}) {
+		t.Error("expected a case insensitive match")
+	}
+	if hasAnyAlias(dist, []string{"example.com"}) {
+		t.Error("expected no match for a parent domain")
+	}
+	if hasAnyAlias(types.DistributionSummary{Id: aws.String("E2")}, []string{"www.example.com"}) {
+		t.Error("expected no match for a distribution without aliases")
+	}
+}
+
+func TestNewCallerReference(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		ref := newCallerReference(i % 3)
+		if seen[ref] {
+			t.Fatalf("duplicate caller reference %s", ref)
+		}
+		seen[ref] = true
+		if !strings.HasSuffix(ref, fmt.Sprintf("-%d", i%3)) {
+			t.Errorf("caller reference %s does not end with its batch", ref)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -64,6 +65,26 @@ func GetConfig(region *string, cfg_files []string, crd_files []string) (aws.Conf
 	return cfg, err
 }
 
+var regionConfigs sync.Map
+
+// regionConfig returns the default config of a region, loaded once per region for the lifetime of the process.
+func regionConfig(region *string) (aws.Config, error) {
+	key := ""
+	if region != nil {
+		key = *region
+	}
+	if cfg, ok := regionConfigs.Load(key); ok {
+		return cfg.(aws.Config), nil
+	}
+
+	cfg, err := GetConfig(region, nil, nil)
+	if err != nil {
+		return cfg, err
+	}
+	regionConfigs.Store(key, cfg)
+	return cfg, nil
+}
+
 // this assumes the parameter is in the format of service::id::region, while region is optional.
 func ParseStr(param string) ConfigStruct {
 	arr := strings.Split(param, "::")
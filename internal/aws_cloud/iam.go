@@ -4,85 +4,471 @@
 package awscloud
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"reflect" // To compare principals and resources accurately
 	"sort"    // For consistent string slice sorting
+	"strings"
 )
 
+// Policy is an IAM policy document.
+// Statement may be written as a single object; that form is kept when marshalling back.
 type Policy struct {
-	Version   string      `json:"Version"`
+	Version   string      `json:"Version,omitempty"`
+	Id        string      `json:"Id,omitempty"`
 	Statement []Statement `json:"Statement"`
+
+	singleStatement bool
 }
 
 type Statement struct {
-	Effect    string      `json:"Effect"`
-	Principal Principal   `json:"Principal,omitempty"`
-	Action    interface{} `json:"Action"`   // Can be string or []string
-	Resource  interface{} `json:"Resource"` // Can be string or []string
-	Condition interface{} `json:"Condition,omitempty"`
-	Sid       string      `json:"Sid,omitempty"` // Optional statement ID
+	Sid          string        `json:"Sid,omitempty"` // Optional statement ID
+	Effect       string        `json:"Effect"`
+	Principal    *Principal    `json:"Principal,omitempty"`
+	NotPrincipal *Principal    `json:"NotPrincipal,omitempty"`
+	Action       StringOrSlice `json:"Action,omitzero"`
+	NotAction    StringOrSlice `json:"NotAction,omitzero"`
+	Resource     StringOrSlice `json:"Resource,omitzero"`
+	NotResource  StringOrSlice `json:"NotResource,omitzero"`
+	Condition    Condition     `json:"Condition,omitzero"`
 }
 
+// Principal is either the "*" wildcard or an object of principal types.
 type Principal struct {
-	AWS           interface{} `json:"AWS,omitempty"` // Can be string or []string
-	Federated     interface{} `json:"Federated,omitempty"`
-	Service       interface{} `json:"Service,omitempty"` // Can be string or []string
-	CanonicalUser string      `json:"CanonicalUser,omitempty"`
-}
-
-// MergePolicies merges two IAM policies based on principal and resource.
-// Actions are only merged if both principal and resource match.
-// Otherwise, statements are added individually.
-func MergePolicies(policy1, policy2 Policy) Policy {
-	mergedPolicy := policy1
-
-	for _, stmt2 := range policy2.Statement {
-		foundMatch := false
-		for i, stmt1 := range mergedPolicy.Statement {
-			// Compare principals AND resources
-			if reflect.DeepEqual(stmt1.Principal, stmt2.Principal) &&
-				reflect.DeepEqual(stmt1.Resource, stmt2.Resource) {
-				// Principals and Resources match, merge actions and conditions
-				mergedPolicy.Statement[i].Action = mergeStringOrStringSlice(stmt1.Action, stmt2.Action)
-				mergedPolicy.Statement[i].Condition = mergeConditions(stmt1.Condition, stmt2.Condition) // Add this line
-				foundMatch = true
-				break
+	Wildcard      bool          `json:"-"`
+	AWS           StringOrSlice `json:"AWS,omitzero"`
+	Federated     StringOrSlice `json:"Federated,omitzero"`
+	Service       StringOrSlice `json:"Service,omitzero"`
+	CanonicalUser StringOrSlice `json:"CanonicalUser,omitzero"`
+}
+
+// Condition maps operators to condition keys to values, e.g. {"StringEquals": {"aws:SourceVpc": "vpc-1"}}.
+type Condition map[string]map[string]ConditionValues
+
+// StringOrSlice holds an IAM value written either as a single string or as a list.
+type StringOrSlice struct {
+	Values []string
+
+	// list remembers a one element list, so it is not collapsed into a string.
+	list bool
+}
+
+// ConditionValues holds condition values, which may be strings, booleans or numbers, alone or in a list.
+type ConditionValues struct {
+	Values []interface{}
+
+	list bool
+}
+
+// NewStringOrSlice returns values that marshal as a string when there is exactly one of them.
+func NewStringOrSlice(values ...string) StringOrSlice {
+	return StringOrSlice{Values: values}
+}
+
+func (s StringOrSlice) IsZero() bool {
+	return s.Values == nil && !s.list
+}
+
+func (s StringOrSlice) MarshalJSON() ([]byte, error) {
+	if len(s.Values) == 1 && !s.list {
+		return json.Marshal(s.Values[0])
+	}
+	if s.Values == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s.Values)
+}
+
+func (s *StringOrSlice) UnmarshalJSON(data []byte) error {
+	*s = StringOrSlice{}
+	if isJSONNull(data) {
+		return nil
+	}
+	if isJSONList(data) {
+		s.list = true
+		s.Values = []string{}
+		return json.Unmarshal(data, &s.Values)
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("expected a string or a list of strings, got %s", data)
+	}
+	s.Values = []string{value}
+	return nil
+}
+
+func (c ConditionValues) IsZero() bool {
+	return c.Values == nil && !c.list
+}
+
+func (c ConditionValues) MarshalJSON() ([]byte, error) {
+	if len(c.Values) == 1 && !c.list {
+		return json.Marshal(c.Values[0])
+	}
+	if c.Values == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(c.Values)
+}
+
+func (c *ConditionValues) UnmarshalJSON(data []byte) error {
+	*c = ConditionValues{}
+	if isJSONNull(data) {
+		return nil
+	}
+
+	// keep numbers exactly as written.
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if isJSONList(data) {
+		c.list = true
+		c.Values = []interface{}{}
+		return decoder.Decode(&c.Values)
+	}
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+	c.Values = []interface{}{value}
+	return nil
+}
+
+func (p Principal) MarshalJSON() ([]byte, error) {
+	if p.Wildcard {
+		return []byte(`"*"`), nil
+	}
+	type principal Principal
+	return json.Marshal(principal(p))
+}
+
+func (p *Principal) UnmarshalJSON(data []byte) error {
+	var wildcard string
+	if err := json.Unmarshal(data, &wildcard); err == nil {
+		if wildcard != "*" {
+			return fmt.Errorf(`principal must be "*" or an object, got %q`, wildcard)
+		}
+		*p = Principal{Wildcard: true}
+		return nil
+	}
+
+	type principal Principal
+	var parsed principal
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return err
+	}
+	*p = Principal(parsed)
+	return nil
+}
+
+func (p Policy) MarshalJSON() ([]byte, error) {
+	type policy Policy
+	if p.singleStatement && len(p.Statement) == 1 {
+		return json.Marshal(struct {
+			Version   string    `json:"Version,omitempty"`
+			Id        string    `json:"Id,omitempty"`
+			Statement Statement `json:"Statement"`
+		}{p.Version, p.Id, p.Statement[0]})
+	}
+	if p.Statement == nil {
+		p.Statement = []Statement{}
+	}
+	return json.Marshal(policy(p))
+}
+
+func (p *Policy) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Version   string          `json:"Version"`
+		Id        string          `json:"Id"`
+		Statement json.RawMessage `json:"Statement"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*p = Policy{Version: raw.Version, Id: raw.Id}
+	if len(raw.Statement) == 0 {
+		return nil
+	}
+
+	if isJSONList(raw.Statement) {
+		return json.Unmarshal(raw.Statement, &p.Statement)
+	}
+
+	var stmt Statement
+	if err := json.Unmarshal(raw.Statement, &stmt); err != nil {
+		return err
+	}
+	p.Statement = []Statement{stmt}
+	p.singleStatement = true
+	return nil
+}
+
+func isJSONList(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+func isJSONNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
+
+// Statement matching strategies for MergeOptions.Match.
+const (
+	// MatchPrincipalResource merges statements with the same effect, principal and resource,
+	// unioning compatible conditions.
+	MatchPrincipalResource = "principal_resource"
+	// MatchPrincipalResourceEffectCondition also requires identical conditions.
+	MatchPrincipalResourceEffectCondition = "principal_resource_effect_condition"
+	// MatchSid merges statements with the same Sid, unioning their actions and resources.
+	MatchSid = "sid"
+)
+
+// Sid clash handling for MergeOptions.OnSidConflict, when a statement that was not merged
+// carries a Sid already used in the merged policy.
+const (
+	SidConflictError    = "error"
+	SidConflictRename   = "rename"
+	SidConflictOverride = "override"
+)
+
+// Action rewriting for MergeOptions.Actions, based on the bundled action catalog.
+const (
+	// ActionsExpand replaces wildcard actions with the actions they match.
+	ActionsExpand = "expand"
+	// ActionsCompact turns explicit action lists back into wildcards.
+	ActionsCompact = "compact"
+)
+
+// MergeOptions controls how MergePolicies combines statements.
+type MergeOptions struct {
+	// Match is one of the Match constants, defaults to MatchPrincipalResource.
+	Match string
+	// OnSidConflict is one of the SidConflict constants, defaults to SidConflictError.
+	OnSidConflict string
+	// MergeDeny allows Deny statements to be merged; otherwise they are kept as written.
+	MergeDeny bool
+	// MergeIncompatibleConditions merges matching statements even when their conditions
+	// differ in operators, keys or single valued operators, by unioning every condition.
+	// This widens what each merged action is allowed under, so it is off by default.
+	MergeIncompatibleConditions bool
+	// Actions is empty to keep actions as written, or one of the Actions constants.
+	Actions string
+}
+
+func (o *MergeOptions) setDefaults() {
+	if o.Match == "" {
+		o.Match = MatchPrincipalResource
+	}
+	if o.OnSidConflict == "" {
+		o.OnSidConflict = SidConflictError
+	}
+}
+
+func (o MergeOptions) validate() error {
+	switch o.Match {
+	case MatchPrincipalResource, MatchPrincipalResourceEffectCondition, MatchSid:
+	default:
+		return fmt.Errorf("unsupported match %q, expected one of %s, %s or %s", o.Match, MatchSid, MatchPrincipalResource, MatchPrincipalResourceEffectCondition)
+	}
+
+	switch o.OnSidConflict {
+	case SidConflictError, SidConflictRename, SidConflictOverride:
+	default:
+		return fmt.Errorf("unsupported sid conflict handling %q, expected one of %s, %s or %s", o.OnSidConflict, SidConflictError, SidConflictRename, SidConflictOverride)
+	}
+
+	switch o.Actions {
+	case "", ActionsExpand, ActionsCompact:
+	default:
+		return fmt.Errorf("unsupported actions %q, expected %s or %s", o.Actions, ActionsExpand, ActionsCompact)
+	}
+
+	return nil
+}
+
+// MergePolicies merges the statements of each policy into the first one.
+// Statements that match an existing statement per opts.Match are combined, the others are appended.
+// Version and Id are taken from the first policy that sets them.
+func MergePolicies(opts MergeOptions, policies ...Policy) (Policy, error) {
+	opts.setDefaults()
+	if err := opts.validate(); err != nil {
+		return Policy{}, err
+	}
+	if len(policies) == 0 {
+		return Policy{}, fmt.Errorf("no policies to merge")
+	}
+
+	mergedPolicy := policies[0]
+	mergedPolicy.Statement = append([]Statement{}, policies[0].Statement...)
+
+	for n, policy := range policies[1:] {
+		if mergedPolicy.Version == "" {
+			mergedPolicy.Version = policy.Version
+		}
+		if mergedPolicy.Id == "" {
+			mergedPolicy.Id = policy.Id
+		}
+
+		for _, stmt := range policy.Statement {
+			var err error
+			mergedPolicy.Statement, err = mergeStatement(mergedPolicy.Statement, stmt, opts)
+			if err != nil {
+				return Policy{}, fmt.Errorf("policy %d: %w", n+2, err)
 			}
 		}
+	}
 
-		// If no matching principal AND resource were found, add the statement from policy2.
-		if !foundMatch {
-			mergedPolicy.Statement = append(mergedPolicy.Statement, stmt2)
+	if err := transformActions(mergedPolicy.Statement, opts.Actions); err != nil {
+		return Policy{}, err
+	}
+
+	return mergedPolicy, nil
+}
+
+// mergeStatement combines stmt into the first matching statement, or appends it.
+func mergeStatement(statements []Statement, stmt Statement, opts MergeOptions) ([]Statement, error) {
+	if stmt.Effect != "Deny" || opts.MergeDeny {
+		for i, existing := range statements {
+			if merged, ok := combineStatements(existing, stmt, opts); ok {
+				statements[i] = merged
+				return statements, nil
+			}
 		}
 	}
 
-	return mergedPolicy
+	if stmt.Sid == "" {
+		return append(statements, stmt), nil
+	}
+
+	for i, existing := range statements {
+		if existing.Sid != stmt.Sid {
+			continue
+		}
+
+		switch opts.OnSidConflict {
+		case SidConflictOverride:
+			statements[i] = stmt
+			return statements, nil
+		case SidConflictRename:
+			stmt.Sid = uniqueSid(statements, stmt.Sid)
+		default:
+			return nil, fmt.Errorf("statement %q clashes with an existing statement of the same Sid", stmt.Sid)
+		}
+		break
+	}
+
+	return append(statements, stmt), nil
 }
 
-// Helper function to merge string or string slice values (Actions/Resources).
-func mergeStringOrStringSlice(val1, val2 interface{}) interface{} {
-	// Handle nil values gracefully
-	if val1 == nil && val2 == nil {
-		return nil
+// combineStatements merges two statements that match per opts.Match.
+func combineStatements(stmt1, stmt2 Statement, opts MergeOptions) (Statement, bool) {
+	// statements of different effects or with NotAction cannot take more actions.
+	if stmt1.Effect != stmt2.Effect ||
+		!stmt1.NotAction.IsZero() || !stmt2.NotAction.IsZero() ||
+		!samePrincipal(stmt1.Principal, stmt2.Principal) ||
+		!samePrincipal(stmt1.NotPrincipal, stmt2.NotPrincipal) {
+		return Statement{}, false
 	}
-	if val1 == nil {
-		return val2
+
+	merged := stmt1
+	switch opts.Match {
+	case MatchSid:
+		// resources are unioned, so NotResource cannot take part.
+		if stmt1.Sid == "" || stmt1.Sid != stmt2.Sid ||
+			!stmt1.NotResource.IsZero() || !stmt2.NotResource.IsZero() {
+			return Statement{}, false
+		}
+		merged.Resource = mergeStringOrStringSlice(stmt1.Resource, stmt2.Resource)
+	default:
+		// Compare principals AND resources
+		if !sameValues(stmt1.Resource, stmt2.Resource) ||
+			!sameValues(stmt1.NotResource, stmt2.NotResource) {
+			return Statement{}, false
+		}
 	}
-	if val2 == nil {
-		return val1
+
+	switch {
+	case sameCondition(stmt1.Condition, stmt2.Condition):
+	case opts.Match == MatchPrincipalResourceEffectCondition:
+		return Statement{}, false
+	default:
+		// unioning condition values keeps access the same only when they are the one difference: with
+		// other actions or resources as well, the merged statement allows combinations neither did.
+		if !opts.MergeIncompatibleConditions && (!sameValues(stmt1.Action, stmt2.Action) ||
+			!sameValues(stmt1.Resource, stmt2.Resource) || !sameValues(stmt1.NotResource, stmt2.NotResource)) {
+			return Statement{}, false
+		}
+		condition, ok := mergeConditions(stmt1.Condition, stmt2.Condition, opts.MergeIncompatibleConditions)
+		if !ok {
+			return Statement{}, false
+		}
+		merged.Condition = condition
 	}
 
-	var s1, s2 []string
+	merged.Action = mergeStringOrStringSlice(stmt1.Action, stmt2.Action)
+	return merged, true
+}
 
-	// Convert interfaces to string slices for processing.
-	s1 = interfaceToStringSlice(val1)
-	s2 = interfaceToStringSlice(val2)
+// sameValues reports whether two values hold the same strings, whether written as a string or a list.
+func sameValues(val1, val2 StringOrSlice) bool {
+	set1 := make(map[string]bool, len(val1.Values))
+	for _, v := range val1.Values {
+		set1[v] = true
+	}
+	set2 := make(map[string]bool, len(val2.Values))
+	for _, v := range val2.Values {
+		set2[v] = true
+	}
+	return reflect.DeepEqual(set1, set2)
+}
+
+// samePrincipal compares principals by their values, the way sameValues does.
+func samePrincipal(p1, p2 *Principal) bool {
+	if p1 == nil || p2 == nil {
+		return p1 == p2
+	}
+	return p1.Wildcard == p2.Wildcard &&
+		sameValues(p1.AWS, p2.AWS) &&
+		sameValues(p1.Federated, p2.Federated) &&
+		sameValues(p1.Service, p2.Service) &&
+		sameValues(p1.CanonicalUser, p2.CanonicalUser)
+}
+
+// uniqueSid returns sid suffixed with the first number not already used in statements.
+func uniqueSid(statements []Statement, sid string) string {
+	used := make(map[string]bool, len(statements))
+	for _, stmt := range statements {
+		used[stmt.Sid] = true
+	}
+
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s%d", sid, n)
+		if !used[candidate] {
+			return candidate
+		}
+	}
+}
+
+// Helper function to merge string or string slice values (Actions/Resources).
+func mergeStringOrStringSlice(val1, val2 StringOrSlice) StringOrSlice {
+	// Handle empty values gracefully
+	if val1.IsZero() {
+		return val2
+	}
+	if val2.IsZero() {
+		return val1
+	}
 
 	// Create a map to store unique values.
 	uniqueValues := make(map[string]bool)
-	for _, s := range s1 {
+	for _, s := range val1.Values {
 		uniqueValues[s] = true
 	}
-	for _, s := range s2 {
+	for _, s := range val2.Values {
 		uniqueValues[s] = true
 	}
 
@@ -95,65 +481,271 @@ func mergeStringOrStringSlice(val1, val2 interface{}) interface{} {
 	// Sort for consistent output, important for DeepEqual on resources later.
 	sort.Strings(merged)
 
-	// If there's only one item, return it as a string to match IAM policy JSON format.
-	if len(merged) == 1 {
-		return merged[0]
+	// If there's only one item, it is written as a string to match IAM policy JSON format.
+	return NewStringOrSlice(merged...)
+}
+
+// mergeConditions merges two IAM condition blocks by unioning the values of each operator and key.
+// It reports false when the blocks are not compatible: they use different operators or keys,
+// or differ in an operator whose values cannot be widened by a union. With force, everything is unioned.
+func mergeConditions(cond1, cond2 Condition, force bool) (Condition, bool) {
+	if reflect.DeepEqual(cond1, cond2) {
+		return cond1, true
+	}
+	if !force && !conditionsCompatible(cond1, cond2) {
+		return nil, false
+	}
+
+	merged := make(Condition)
+	for _, cond := range []Condition{cond1, cond2} {
+		for operator, keys := range cond {
+			if merged[operator] == nil {
+				merged[operator] = make(map[string]ConditionValues)
+			}
+			for key, values := range keys {
+				merged[operator][key] = unionConditionValues(merged[operator][key], values)
+			}
+		}
 	}
 
-	return merged
+	return merged, true
 }
 
-// Helper to convert interface{} to []string.
-func interfaceToStringSlice(val interface{}) []string {
-	var result []string
-	if str, ok := val.(string); ok {
-		result = []string{str}
-	} else if slice, ok := val.([]interface{}); ok {
-		for _, v := range slice {
-			if str, ok := v.(string); ok {
-				result = append(result, str)
+// conditionsCompatible reports whether two condition blocks can be unioned without widening
+// access beyond the values themselves: same operators and keys, with the values of exactly one
+// operator and key differing, in an operator where more values means more matches. Unioning two
+// keys at once would also match one key's old values together with the other key's new ones.
+func conditionsCompatible(cond1, cond2 Condition) bool {
+	if len(cond1) != len(cond2) {
+		return false
+	}
+
+	differing := 0
+	for operator, keys1 := range cond1 {
+		keys2, ok := cond2[operator]
+		if !ok || len(keys1) != len(keys2) {
+			return false
+		}
+
+		for key, values1 := range keys1 {
+			values2, ok := keys2[key]
+			if !ok {
+				return false
 			}
+			if sameConditionValues(values1, values2) {
+				continue
+			}
+			if !unionableOperator(operator) {
+				return false
+			}
+			differing++
 		}
-	} else if slice, ok := val.([]string); ok {
-		result = slice
 	}
-	return result
+
+	return differing == 1
 }
 
-// mergeConditions merges two IAM condition blocks.
-// Merge them by combining keys. Conflicts will overwrite.
-func mergeConditions(cond1, cond2 interface{}) interface{} {
-	// If both are nil, return nil.
-	if cond1 == nil && cond2 == nil {
-		return nil
+// unionableOperator reports whether adding values to the operator only adds matches.
+// Negated operators exclude more with more values, and Bool and Null hold a single value.
+func unionableOperator(operator string) bool {
+	base := operator
+	if i := strings.LastIndex(base, ":"); i >= 0 {
+		base = base[i+1:]
+	}
+	base = strings.TrimSuffix(base, "IfExists")
+
+	if base == "Bool" || base == "Null" {
+		return false
+	}
+	return !strings.Contains(base, "Not")
+}
+
+func sameConditionValues(values1, values2 ConditionValues) bool {
+	return reflect.DeepEqual(conditionValueSet(values1), conditionValueSet(values2))
+}
+
+func conditionValueSet(values ConditionValues) map[string]bool {
+	set := make(map[string]bool, len(values.Values))
+	for _, v := range values.Values {
+		set[fmt.Sprint(v)] = true
 	}
-	// If one is nil, return the other.
-	if cond1 == nil {
-		return cond2
+	return set
+}
+
+// unionConditionValues appends the values of values2 missing from values1, keeping their order.
+// Values compare by their text, as IAM compares "true" and true alike.
+func unionConditionValues(values1, values2 ConditionValues) ConditionValues {
+	seen := make(map[string]bool)
+	var union []interface{}
+	for _, v := range append(append([]interface{}{}, values1.Values...), values2.Values...) {
+		key := fmt.Sprint(v)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		union = append(union, v)
 	}
-	if cond2 == nil {
-		return cond1
+
+	if len(union) == 1 && !values1.list && !values2.list {
+		return ConditionValues{Values: union}
 	}
+	return ConditionValues{Values: union, list: len(union) > 1 || values1.list || values2.list}
+}
 
-	map1, ok1 := cond1.(map[string]interface{})
-	map2, ok2 := cond2.(map[string]interface{})
+// MatchWildcard reports whether value matches an IAM pattern, where * matches any run of
+// characters and ? a single one. Actions compare case insensitively, resources do not.
+func MatchWildcard(pattern, value string, caseInsensitive bool) bool {
+	if caseInsensitive {
+		pattern = strings.ToLower(pattern)
+		value = strings.ToLower(value)
+	}
 
-	// If both are maps, proceed with merging them.
-	if ok1 && ok2 {
-		mergedMap := make(map[string]interface{})
-		// Copy all from map1.
-		for k, v := range map1 {
-			mergedMap[k] = v
+	p, v := 0, 0
+	star, mark := -1, 0
+	for v < len(value) {
+		switch {
+		// a * in the pattern is a wildcard even when the value holds one too.
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, v
+			p++
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == value[v]):
+			p++
+			v++
+		case star >= 0:
+			// backtrack: let the last * swallow one more character.
+			mark++
+			p, v = star+1, mark
+		default:
+			return false
 		}
-		// Copy or overwrite from map2.
-		for k, v := range map2 {
-			// **Here's a simplified merge strategy: Overwrite on conflict.**.
-			// In a real-world scenario, you'd need more sophisticated logic.
-			// for different condition operators (e.g., merging arrays for ForAllValues).
-			mergedMap[k] = v
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// NormalizePolicy returns the canonical form of a policy: statements are deduplicated, every list is
+// deduplicated and sorted, actions covered by a wildcard action of the same statement are removed, and
+// one element lists are written as strings. Marshalling it gives stable JSON.
+func NormalizePolicy(policy Policy) Policy {
+	normalized := Policy{Version: policy.Version, Id: policy.Id, Statement: []Statement{}}
+
+	for _, stmt := range policy.Statement {
+		stmt.Action = normalizeActions(stmt.Action)
+		stmt.NotAction = normalizeActions(stmt.NotAction)
+		stmt.Resource = normalizeValues(stmt.Resource)
+		stmt.NotResource = normalizeValues(stmt.NotResource)
+		stmt.Principal = normalizePrincipal(stmt.Principal)
+		stmt.NotPrincipal = normalizePrincipal(stmt.NotPrincipal)
+		stmt.Condition = normalizeCondition(stmt.Condition)
+
+		duplicate := false
+		for _, existing := range normalized.Statement {
+			if reflect.DeepEqual(existing, stmt) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			normalized.Statement = append(normalized.Statement, stmt)
+		}
+	}
+
+	return normalized
+}
+
+// normalizeValues dedupes and sorts values, writing a single value as a string.
+func normalizeValues(values StringOrSlice) StringOrSlice {
+	if values.IsZero() {
+		return values
+	}
+
+	seen := make(map[string]bool, len(values.Values))
+	unique := []string{}
+	for _, v := range values.Values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	sort.Strings(unique)
+
+	return StringOrSlice{Values: unique, list: len(unique) != 1}
+}
+
+// normalizeActions is normalizeValues for actions, which also drops actions a wildcard
+// action of the same list already covers. Actions are case insensitive.
+func normalizeActions(actions StringOrSlice) StringOrSlice {
+	if actions.IsZero() {
+		return actions
+	}
+
+	seen := make(map[string]bool, len(actions.Values))
+	var unique []string
+	for _, a := range actions.Values {
+		if !seen[strings.ToLower(a)] {
+			seen[strings.ToLower(a)] = true
+			unique = append(unique, a)
+		}
+	}
+
+	kept := []string{}
+	for i, a := range unique {
+		covered := false
+		for j, pattern := range unique {
+			if i != j && strings.ContainsAny(pattern, "*?") && MatchWildcard(pattern, a, true) &&
+				// of two wildcards covering each other, keep the first.
+				(!MatchWildcard(a, pattern, true) || j < i) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			kept = append(kept, a)
+		}
+	}
+
+	sort.Slice(kept, func(i, j int) bool {
+		return strings.ToLower(kept[i]) < strings.ToLower(kept[j])
+	})
+
+	return StringOrSlice{Values: kept, list: len(kept) != 1}
+}
+
+func normalizePrincipal(principal *Principal) *Principal {
+	if principal == nil || principal.Wildcard {
+		return principal
+	}
+
+	return &Principal{
+		AWS:           normalizeValues(principal.AWS),
+		Federated:     normalizeValues(principal.Federated),
+		Service:       normalizeValues(principal.Service),
+		CanonicalUser: normalizeValues(principal.CanonicalUser),
+	}
+}
+
+func normalizeCondition(condition Condition) Condition {
+	if condition == nil {
+		return nil
+	}
+
+	normalized := make(Condition, len(condition))
+	for operator, keys := range condition {
+		normalized[operator] = make(map[string]ConditionValues, len(keys))
+		for key, values := range keys {
+			unique := unionConditionValues(values, ConditionValues{}).Values
+			if unique == nil {
+				unique = []interface{}{}
+			}
+			sort.SliceStable(unique, func(i, j int) bool {
+				return fmt.Sprint(unique[i]) < fmt.Sprint(unique[j])
+			})
+			normalized[operator][key] = ConditionValues{Values: unique, list: len(unique) != 1}
 		}
-		return mergedMap
 	}
 
-	return cond1
+	return normalized
 }
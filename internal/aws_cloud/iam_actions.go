@@ -0,0 +1,336 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// The catalog is generated from the IAM service authorization reference with cmd/iamcatalog, see the
+// actions-catalog make target. Only the services it lists as complete hold every action of the service.
+//
+//go:embed iam_actions.json
+var actionCatalogJSON []byte
+
+// Access levels of the IAM service authorization reference.
+const (
+	AccessLevelList                  = "List"
+	AccessLevelRead                  = "Read"
+	AccessLevelWrite                 = "Write"
+	AccessLevelPermissionsManagement = "Permissions management"
+	AccessLevelTagging               = "Tagging"
+)
+
+// ActionInfo describes an IAM action in the catalog.
+type ActionInfo struct {
+	AccessLevel   string   `json:"access_level"`
+	ResourceTypes []string `json:"resource_types,omitempty"`
+}
+
+// ActionCatalog maps service prefixes to action names to their description.
+type ActionCatalog map[string]map[string]ActionInfo
+
+// ActionCatalogFile is the layout of the catalog file written by cmd/iamcatalog.
+type ActionCatalogFile struct {
+	// Complete lists the services whose every action is in the catalog, as read from the full reference.
+	// Wildcards of other services may match actions the catalog does not know, so they are never expanded
+	// or produced.
+	Complete []string      `json:"complete"`
+	Services ActionCatalog `json:"services"`
+}
+
+var (
+	actionCatalogOnce sync.Once
+	actionCatalog     ActionCatalog
+	// actionNames holds the sorted action names of each service.
+	actionNames map[string][]string
+	// actionComplete holds the services whose every action is in the catalog.
+	actionComplete map[string]bool
+)
+
+// BundledActions returns the bundled action catalog.
+func BundledActions() ActionCatalog {
+	actionCatalogOnce.Do(func() {
+		if err := loadActionCatalog(actionCatalogJSON); err != nil {
+			panic(fmt.Sprintf("invalid bundled action catalog: %s", err))
+		}
+	})
+	return actionCatalog
+}
+
+func loadActionCatalog(content []byte) error {
+	var file ActionCatalogFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return err
+	}
+
+	actionCatalog = file.Services
+	actionNames = make(map[string][]string, len(actionCatalog))
+	for service, actions := range actionCatalog {
+		for name := range actions {
+			actionNames[service] = append(actionNames[service], name)
+		}
+		sort.Strings(actionNames[service])
+	}
+	actionComplete = make(map[string]bool, len(file.Complete))
+	for _, service := range file.Complete {
+		actionComplete[service] = true
+	}
+	return nil
+}
+
+// completeService reports whether every action of the service is in the catalog.
+func completeService(service string) bool {
+	BundledActions()
+	return actionComplete[service]
+}
+
+// catalogMatches returns the catalog actions matching a pattern, which for a service that is not
+// complete may be only some of the actions it grants.
+func catalogMatches(pattern string) []string {
+	BundledActions()
+	service, name, ok := splitAction(pattern)
+	if pattern == "*" {
+		service, name, ok = "*", "*", true
+	}
+	if !ok {
+		return nil
+	}
+
+	var matches []string
+	for candidateService, names := range actionNames {
+		if !MatchWildcard(service, candidateService, true) {
+			continue
+		}
+		for _, candidate := range names {
+			if MatchWildcard(name, candidate, true) {
+				matches = append(matches, candidateService+":"+candidate)
+			}
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// splitAction splits service:Action, lower casing the service prefix.
+func splitAction(action string) (string, string, bool) {
+	service, name, ok := strings.Cut(action, ":")
+	return strings.ToLower(service), name, ok
+}
+
+// LookupAction returns the catalog entry of an action, matching the name case insensitively.
+func LookupAction(action string) (string, ActionInfo, bool) {
+	service, name, ok := splitAction(action)
+	if !ok {
+		return "", ActionInfo{}, false
+	}
+
+	actions := BundledActions()[service]
+	if info, ok := actions[name]; ok {
+		return service + ":" + name, info, true
+	}
+	for candidate, info := range actions {
+		if strings.EqualFold(candidate, name) {
+			return service + ":" + candidate, info, true
+		}
+	}
+	return "", ActionInfo{}, false
+}
+
+// ExpandActions replaces wildcard actions of complete services with the catalog actions they match,
+// returned sorted and deduplicated. A pattern of a complete service that matches no action is an error.
+// "*" and the wildcards of other services may grant actions the catalog does not know, so they are kept
+// as written, as are actions of services missing from the catalog.
+func ExpandActions(patterns []string) ([]string, error) {
+	catalog := BundledActions()
+	seen := make(map[string]bool)
+	var expanded []string
+	add := func(action string) {
+		if !seen[action] {
+			seen[action] = true
+			expanded = append(expanded, action)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if pattern == "*" {
+			add(pattern)
+			continue
+		}
+
+		service, name, ok := splitAction(pattern)
+		if !ok {
+			return nil, fmt.Errorf("invalid action %q, expected service:action", pattern)
+		}
+		if _, known := catalog[service]; !known || strings.ContainsAny(service, "*?") {
+			add(pattern)
+			continue
+		}
+		if !completeService(service) {
+			if canonical, _, found := LookupAction(pattern); found {
+				add(canonical)
+			} else {
+				add(pattern)
+			}
+			continue
+		}
+
+		matched := false
+		for _, candidate := range actionNames[service] {
+			if MatchWildcard(name, candidate, true) {
+				add(service + ":" + candidate)
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("%s matches no %s action in the catalog", pattern, service)
+		}
+	}
+
+	sort.Strings(expanded)
+	return expanded, nil
+}
+
+// CompactActions turns explicit action lists of complete services back into wildcards: all actions of a
+// service become service:*, otherwise the shortest leading words of an action name whose wildcard matches
+// only listed actions are used, e.g. s3:GetObject and s3:GetObjectAcl become s3:GetObject* when s3 has no
+// other GetObject action. Wildcards of complete services are expanded first. A wildcard of another service
+// could grant actions the catalog does not know, so their actions and wildcards are kept as written, as are
+// actions missing from the catalog.
+func CompactActions(actions []string) []string {
+	catalog := BundledActions()
+	if slices.Contains(actions, "*") {
+		return []string{"*"}
+	}
+
+	listed := make(map[string]map[string]bool)
+	var compacted []string
+	for _, action := range actions {
+		expanded := []string{action}
+		if strings.ContainsAny(action, "*?") {
+			if all, err := ExpandActions(expanded); err == nil {
+				expanded = all
+			}
+		}
+
+		for _, a := range expanded {
+			canonical, _, ok := LookupAction(a)
+			service, name, _ := splitAction(canonical)
+			if !ok || !completeService(service) {
+				if ok {
+					a = canonical
+				}
+				if !slices.Contains(compacted, a) {
+					compacted = append(compacted, a)
+				}
+				continue
+			}
+			if listed[service] == nil {
+				listed[service] = make(map[string]bool)
+			}
+			listed[service][name] = true
+		}
+	}
+
+	services := make([]string, 0, len(listed))
+	for service := range listed {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	for _, service := range services {
+		names := listed[service]
+		if len(names) == len(catalog[service]) {
+			compacted = append(compacted, service+":*")
+			continue
+		}
+
+		covered := make(map[string]bool)
+		for _, name := range actionNames[service] {
+			if !names[name] || covered[name] {
+				continue
+			}
+			compacted = append(compacted, service+":"+compactAction(service, name, names, covered))
+		}
+	}
+
+	return compacted
+}
+
+// compactAction returns the shortest wildcard of the leading words of name that matches more than
+// one action and only listed ones, marking the matched actions covered.
+func compactAction(service, name string, listed, covered map[string]bool) string {
+	words := camelWords(name)
+	for n := 1; n <= len(words); n++ {
+		prefix := strings.Join(words[:n], "")
+
+		var matches []string
+		for _, candidate := range actionNames[service] {
+			if strings.HasPrefix(candidate, prefix) {
+				matches = append(matches, candidate)
+			}
+		}
+
+		if len(matches) < 2 || slices.ContainsFunc(matches, func(m string) bool { return !listed[m] }) {
+			continue
+		}
+
+		for _, m := range matches {
+			covered[m] = true
+		}
+		return prefix + "*"
+	}
+
+	covered[name] = true
+	return name
+}
+
+// camelWords splits an action name such as GetBucketPolicyStatus into its words.
+func camelWords(name string) []string {
+	var words []string
+	start := 0
+	for i, r := range name {
+		if i > start && unicode.IsUpper(r) && !unicode.IsUpper(rune(name[i-1])) {
+			words = append(words, name[start:i])
+			start = i
+		}
+	}
+	return append(words, name[start:])
+}
+
+// transformActions expands or compacts the actions of every statement per MergeOptions.Actions. NotAction
+// is left as written: the catalog cannot tell what an expanded or compacted NotAction would stop excluding.
+func transformActions(statements []Statement, mode string) error {
+	for i := range statements {
+		values := &statements[i].Action
+		if len(values.Values) == 0 {
+			continue
+		}
+
+		var actions []string
+		switch mode {
+		case ActionsExpand:
+			var err error
+			actions, err = ExpandActions(values.Values)
+			if err != nil {
+				return fmt.Errorf("statement %d: %w", i, err)
+			}
+		case ActionsCompact:
+			actions = CompactActions(values.Values)
+		default:
+			continue
+		}
+
+		values.Values = actions
+		values.list = len(actions) > 1 || values.list
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// testActionCatalog lists every sqs action and only some s3 ones.
+const testActionCatalog = `{
+"complete":["sqs"],
+"services":{
+"s3":{"GetObject":{"access_level":"Read"},"GetObjectAcl":{"access_level":"Read"},"PutObject":{"access_level":"Write"},"PutBucketPolicy":{"access_level":"Permissions management"}},
+"sqs":{"DeleteMessage":{"access_level":"Write"},"GetQueueAttributes":{"access_level":"Read"},"GetQueueUrl":{"access_level":"Read"},"ListQueues":{"access_level":"List"},"ReceiveMessage":{"access_level":"Read"},"SendMessage":{"access_level":"Write"}}
+}
+}`
+
+// useActionCatalog replaces the bundled catalog for the duration of the test.
+func useActionCatalog(t *testing.T, content string) {
+	t.Helper()
+	BundledActions()
+	catalog, names, complete := actionCatalog, actionNames, actionComplete
+	t.Cleanup(func() { actionCatalog, actionNames, actionComplete = catalog, names, complete })
+
+	if err := loadActionCatalog([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBundledActions(t *testing.T) {
+	if _, info, ok := LookupAction("s3:putbucketpolicy"); !ok || info.AccessLevel != AccessLevelPermissionsManagement {
+		t.Errorf("unexpected access level %q", info.AccessLevel)
+	}
+
+	// the wildcard of a complete service expands to every one of its actions, which compact back to it.
+	for service, actions := range BundledActions() {
+		if !completeService(service) {
+			continue
+		}
+		expanded, err := ExpandActions([]string{service + ":*"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(expanded) != len(actions) {
+			t.Errorf("%s:* expands to %d actions, want %d", service, len(expanded), len(actions))
+		}
+		if got := CompactActions(expanded); !slices.Equal(got, []string{service + ":*"}) {
+			t.Errorf("CompactActions(%s) = %v", service, got)
+		}
+	}
+}
+
+func TestExpandActions(t *testing.T) {
+	useActionCatalog(t, testActionCatalog)
+
+	got, err := ExpandActions([]string{"sqs:*Message", "SQS:deletemessage", "ec2:Describe*", "s3:Get*", "S3:getobject", "*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"*", "ec2:Describe*", "s3:Get*", "s3:GetObject", "sqs:DeleteMessage", "sqs:ReceiveMessage", "sqs:SendMessage"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := ExpandActions([]string{"sqs:Recieve*"}); err == nil {
+		t.Error("expected an error for a pattern matching no action")
+	}
+	if got, err := ExpandActions([]string{"s3:CreateAccessPoint"}); err != nil || !slices.Equal(got, []string{"s3:CreateAccessPoint"}) {
+		t.Errorf("got %v, %v, want an action missing from a partial service kept", got, err)
+	}
+}
+
+func TestCompactActions(t *testing.T) {
+	useActionCatalog(t, testActionCatalog)
+
+	cases := []struct {
+		actions []string
+		want    []string
+	}{
+		{[]string{"sqs:DeleteMessage", "sqs:GetQueueAttributes", "sqs:GetQueueUrl", "sqs:ListQueues", "sqs:ReceiveMessage", "sqs:SendMessage"}, []string{"sqs:*"}},
+		{[]string{"sqs:SendMessage", "sqs:GetQueueUrl", "sqs:GetQueueAttributes"}, []string{"sqs:Get*", "sqs:SendMessage"}},
+		{[]string{"sqs:Get*", "sqs:GetQueueUrl"}, []string{"sqs:Get*"}},
+		{[]string{"s3:GetObject", "ec2:RunInstances"}, []string{"ec2:RunInstances", "s3:GetObject"}},
+		// s3 may have actions the catalog does not know, its lists are never turned into wildcards.
+		{[]string{"s3:GetObject", "s3:GetObjectAcl", "s3:PutObject", "s3:PutBucketPolicy"}, []string{"s3:GetObject", "s3:GetObjectAcl", "s3:PutBucketPolicy", "s3:PutObject"}},
+		{[]string{"s3:Put*", "s3:getobject"}, []string{"s3:GetObject", "s3:Put*"}},
+		{[]string{"*", "sqs:SendMessage"}, []string{"*"}},
+	}
+
+	for _, c := range cases {
+		got := CompactActions(c.actions)
+		slices.Sort(got)
+		if !slices.Equal(got, c.want) {
+			t.Errorf("CompactActions(%v) = %v, want %v", c.actions, got, c.want)
+		}
+	}
+}
+
+func TestMergePoliciesExpandActions(t *testing.T) {
+	useActionCatalog(t, testActionCatalog)
+
+	var policy Policy
+	if err := json.Unmarshal([]byte(`{"Statement":[{"Effect":"Allow","Action":"sqs:*Message","Resource":"*"},{"Effect":"Deny","NotAction":"sqs:*Message","Resource":"*"}]}`), &policy); err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := MergePolicies(MergeOptions{Actions: ActionsExpand}, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := json.Marshal(merged)
+	want := `{"Statement":[{"Effect":"Allow","Action":["sqs:DeleteMessage","sqs:ReceiveMessage","sqs:SendMessage"],"Resource":"*"},{"Effect":"Deny","NotAction":"sqs:*Message","Resource":"*"}]}`
+	if string(got) != want {
+		t.Errorf("got %s", got)
+	}
+}
+
+// Expanding or compacting actions must not change what a policy allows, including actions the
+// catalog does not know.
+func TestMergePoliciesActionsKeepDecisions(t *testing.T) {
+	useActionCatalog(t, testActionCatalog)
+
+	policies := []string{
+		`{"Statement":[{"Effect":"Allow","NotAction":"s3:*","Resource":"*"}]}`,
+		`{"Statement":[{"Effect":"Allow","NotAction":["sqs:Get*","s3:Get*"],"Resource":"*"}]}`,
+		`{"Statement":[{"Effect":"Allow","Action":"*","Resource":"*"},{"Effect":"Deny","Action":"sqs:Send*","Resource":"*"}]}`,
+		`{"Statement":[{"Effect":"Allow","Action":["s3:Put*","s3:GetObject","s3:GetObjectAcl"],"Resource":"*"}]}`,
+		`{"Statement":[{"Effect":"Allow","Action":["s3:PutObject","s3:PutBucketPolicy","sqs:*"],"Resource":"*"}]}`,
+		`{"Statement":[{"Effect":"Allow","Action":["sqs:GetQueueUrl","sqs:GetQueueAttributes","sqs:ListQueues"],"Resource":"*"}]}`,
+	}
+	actions := []string{
+		"s3:GetObject", "s3:PutObject", "s3:PutAccessPointPolicy", "s3:CreateJob", "s3:GetBucketTagging",
+		"sqs:SendMessage", "sqs:GetQueueUrl", "sqs:ListQueues", "ec2:RunInstances",
+	}
+
+	for _, document := range policies {
+		var policy Policy
+		if err := json.Unmarshal([]byte(document), &policy); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, mode := range []string{ActionsExpand, ActionsCompact} {
+			var copied Policy
+			_ = json.Unmarshal([]byte(document), &copied)
+			transformed, err := MergePolicies(MergeOptions{Actions: mode}, copied)
+			if err != nil {
+				t.Fatalf("%s %s: %v", mode, document, err)
+			}
+
+			for _, action := range actions {
+				req := EvalRequest{Action: action, Resource: "arn:aws:s3:::b/k"}
+				before, err := EvaluatePolicies([]Policy{policy}, req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				after, err := EvaluatePolicies([]Policy{transformed}, req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if before.Decision != after.Decision {
+					out, _ := json.Marshal(transformed)
+					t.Errorf("%s of %s gave %s: %s is %s, was %s", mode, document, out, action, after.Decision, before.Decision)
+				}
+			}
+		}
+	}
+}
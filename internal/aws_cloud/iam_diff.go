@@ -0,0 +1,233 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Permission is one effect, principal, action and resource combination of a statement.
+// Values from NotPrincipal, NotAction and NotResource are prefixed with "!", principals with their type,
+// e.g. "AWS:arn:aws:iam::111122223333:root". Principal is empty for statements without one.
+type Permission struct {
+	Effect    string
+	Principal string
+	Action    string
+	Resource  string
+	// Condition is the canonical JSON of the statement condition, empty when unconditional.
+	Condition string
+}
+
+// ConditionChange is a permission granted or denied under different conditions.
+type ConditionChange struct {
+	Effect        string
+	Principal     string
+	Action        string
+	Resource      string
+	OldConditions []string
+	NewConditions []string
+}
+
+// PolicyDiff is the difference between two policies, permission by permission.
+type PolicyDiff struct {
+	Added             []Permission
+	Removed           []Permission
+	ChangedConditions []ConditionChange
+	// WidensAccess is set, conservatively, when a change may allow something the old policy did not.
+	WidensAccess bool
+}
+
+func (p Permission) key() string {
+	return strings.Join([]string{p.Effect, p.Principal, p.Action, p.Resource}, "\x00")
+}
+
+// DiffPolicies compares two policies after normalizing them.
+func DiffPolicies(oldPolicy, newPolicy Policy) PolicyDiff {
+	oldPerms := groupPermissions(ExpandPermissions(NormalizePolicy(oldPolicy)))
+	newPerms := groupPermissions(ExpandPermissions(NormalizePolicy(newPolicy)))
+
+	diff := PolicyDiff{Added: []Permission{}, Removed: []Permission{}, ChangedConditions: []ConditionChange{}}
+
+	for _, key := range sortedKeys(newPerms) {
+		perms := newPerms[key]
+		old, ok := oldPerms[key]
+		if !ok {
+			diff.Added = append(diff.Added, perms...)
+			if perms[0].Effect == "Allow" && !coveredByAllow(perms[0], oldPerms) {
+				diff.WidensAccess = true
+			}
+			continue
+		}
+
+		oldConditions, newConditions := permissionConditions(old), permissionConditions(perms)
+		if strings.Join(oldConditions, "\n") == strings.Join(newConditions, "\n") {
+			continue
+		}
+
+		diff.ChangedConditions = append(diff.ChangedConditions, ConditionChange{
+			Effect:        perms[0].Effect,
+			Principal:     perms[0].Principal,
+			Action:        perms[0].Action,
+			Resource:      perms[0].Resource,
+			OldConditions: oldConditions,
+			NewConditions: newConditions,
+		})
+
+		// an Allow gaining a condition set, or a Deny losing one, may apply more widely. An unconditional
+		// Allow before, or an unconditional Deny after, already applies to every request.
+		if (perms[0].Effect == "Allow" && !slices.Contains(oldConditions, "") && !subset(newConditions, oldConditions)) ||
+			(perms[0].Effect == "Deny" && !slices.Contains(newConditions, "") && !subset(oldConditions, newConditions)) {
+			diff.WidensAccess = true
+		}
+	}
+
+	for _, key := range sortedKeys(oldPerms) {
+		if _, ok := newPerms[key]; ok {
+			continue
+		}
+		perms := oldPerms[key]
+		diff.Removed = append(diff.Removed, perms...)
+		// removing a Deny, or an exclusion of an Allow NotPrincipal, NotAction or NotResource, may allow more.
+		if perms[0].Effect == "Deny" || excludes(perms[0]) {
+			diff.WidensAccess = true
+		}
+	}
+
+	return diff
+}
+
+// ExpandPermissions breaks every statement of the policy into permissions.
+func ExpandPermissions(policy Policy) []Permission {
+	var perms []Permission
+
+	for _, stmt := range policy.Statement {
+		condition := ""
+		if len(stmt.Condition) > 0 {
+			encoded, _ := json.Marshal(normalizeCondition(stmt.Condition))
+			condition = string(encoded)
+		}
+
+		principals := append(principalValues(stmt.Principal, ""), principalValues(stmt.NotPrincipal, "!")...)
+		if len(principals) == 0 {
+			principals = []string{""}
+		}
+		actions := append(prefixValues(stmt.Action.Values, ""), prefixValues(stmt.NotAction.Values, "!")...)
+		resources := append(prefixValues(stmt.Resource.Values, ""), prefixValues(stmt.NotResource.Values, "!")...)
+		if len(resources) == 0 {
+			resources = []string{""}
+		}
+
+		for _, principal := range principals {
+			for _, action := range actions {
+				for _, resource := range resources {
+					perms = append(perms, Permission{
+						Effect:    stmt.Effect,
+						Principal: principal,
+						Action:    action,
+						Resource:  resource,
+						Condition: condition,
+					})
+				}
+			}
+		}
+	}
+
+	return perms
+}
+
+func principalValues(principal *Principal, prefix string) []string {
+	if principal == nil {
+		return nil
+	}
+	if principal.Wildcard {
+		return []string{prefix + "*"}
+	}
+
+	var values []string
+	values = append(values, prefixValues(principal.AWS.Values, prefix+"AWS:")...)
+	values = append(values, prefixValues(principal.Federated.Values, prefix+"Federated:")...)
+	values = append(values, prefixValues(principal.Service.Values, prefix+"Service:")...)
+	values = append(values, prefixValues(principal.CanonicalUser.Values, prefix+"CanonicalUser:")...)
+	return values
+}
+
+func prefixValues(values []string, prefix string) []string {
+	prefixed := make([]string, len(values))
+	for i, v := range values {
+		prefixed[i] = prefix + v
+	}
+	return prefixed
+}
+
+func groupPermissions(perms []Permission) map[string][]Permission {
+	grouped := make(map[string][]Permission)
+	for _, p := range perms {
+		grouped[p.key()] = append(grouped[p.key()], p)
+	}
+	return grouped
+}
+
+func sortedKeys(grouped map[string][]Permission) []string {
+	keys := make([]string, 0, len(grouped))
+	for k := range grouped {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func permissionConditions(perms []Permission) []string {
+	seen := make(map[string]bool)
+	conditions := []string{}
+	for _, p := range perms {
+		if !seen[p.Condition] {
+			seen[p.Condition] = true
+			conditions = append(conditions, p.Condition)
+		}
+	}
+	sort.Strings(conditions)
+	return conditions
+}
+
+func subset(values, of []string) bool {
+	set := make(map[string]bool, len(of))
+	for _, v := range of {
+		set[v] = true
+	}
+	for _, v := range values {
+		if !set[v] {
+			return false
+		}
+	}
+	return true
+}
+
+// excludes reports whether the permission is an exclusion of an Allow statement.
+func excludes(perm Permission) bool {
+	return perm.Effect == "Allow" &&
+		(strings.HasPrefix(perm.Principal, "!") || strings.HasPrefix(perm.Action, "!") || strings.HasPrefix(perm.Resource, "!"))
+}
+
+// coveredByAllow reports whether an old unconditional Allow of the same principal already
+// grants the permission through wildcards, so adding it grants nothing new.
+func coveredByAllow(perm Permission, oldPerms map[string][]Permission) bool {
+	if strings.HasPrefix(perm.Action, "!") || strings.HasPrefix(perm.Resource, "!") {
+		return false
+	}
+
+	for _, perms := range oldPerms {
+		for _, old := range perms {
+			if old.Effect == "Allow" && old.Condition == "" && old.Principal == perm.Principal &&
+				!strings.HasPrefix(old.Action, "!") && !strings.HasPrefix(old.Resource, "!") &&
+				MatchWildcard(old.Action, perm.Action, true) &&
+				MatchWildcard(old.Resource, perm.Resource, false) {
+				return true
+			}
+		}
+	}
+	return false
+}
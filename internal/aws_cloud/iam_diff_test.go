@@ -0,0 +1,135 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiffPolicies(t *testing.T) {
+	oldPolicy := `{"Statement":[
+		{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:root"},"Action":["s3:*"],"Resource":"arn:aws:s3:::b/*"},
+		{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::2:root"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*","Condition":{"StringEquals":{"aws:SourceVpc":"vpc-1"}}},
+		{"Effect":"Deny","Principal":"*","Action":"s3:DeleteBucket","Resource":"arn:aws:s3:::b"}
+	]}`
+
+	cases := []struct {
+		name    string
+		old     string
+		policy  string
+		added   []string
+		removed []string
+		changed []string
+		widens  bool
+	}{
+		{
+			name:   "unchanged",
+			policy: oldPolicy,
+		},
+		{
+			name: "covered action added",
+			policy: `{"Statement":[
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:root"},"Action":"s3:*","Resource":"arn:aws:s3:::b/*"},
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:root"},"Action":"s3:PutObject","Resource":"arn:aws:s3:::b/x","Condition":{"Bool":{"aws:SecureTransport":"true"}}},
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::2:root"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*","Condition":{"StringEquals":{"aws:SourceVpc":"vpc-1"}}},
+				{"Effect":"Deny","Principal":"*","Action":"s3:DeleteBucket","Resource":"arn:aws:s3:::b"}
+			]}`,
+			added: []string{"s3:PutObject"},
+		},
+		{
+			name: "deny removed and condition changed",
+			policy: `{"Statement":[
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:root"},"Action":"s3:*","Resource":"arn:aws:s3:::b/*"},
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::2:root"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*","Condition":{"StringEquals":{"aws:SourceVpc":["vpc-1","vpc-2"]}}}
+			]}`,
+			removed: []string{"s3:DeleteBucket"},
+			changed: []string{"s3:GetObject"},
+			widens:  true,
+		},
+		{
+			name: "condition added to an unconditional allow",
+			policy: `{"Statement":[
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:root"},"Action":"s3:*","Resource":"arn:aws:s3:::b/*","Condition":{"Bool":{"aws:SecureTransport":"true"}}},
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::2:root"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*","Condition":{"StringEquals":{"aws:SourceVpc":"vpc-1"}}},
+				{"Effect":"Deny","Principal":"*","Action":"s3:DeleteBucket","Resource":"arn:aws:s3:::b"}
+			]}`,
+			changed: []string{"s3:*"},
+		},
+		{
+			name: "condition added to a deny",
+			policy: `{"Statement":[
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:root"},"Action":"s3:*","Resource":"arn:aws:s3:::b/*"},
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::2:root"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*","Condition":{"StringEquals":{"aws:SourceVpc":"vpc-1"}}},
+				{"Effect":"Deny","Principal":"*","Action":"s3:DeleteBucket","Resource":"arn:aws:s3:::b","Condition":{"Bool":{"aws:SecureTransport":"false"}}}
+			]}`,
+			changed: []string{"s3:DeleteBucket"},
+			widens:  true,
+		},
+		{
+			name: "new principal",
+			policy: `{"Statement":[
+				{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::1:root","arn:aws:iam::3:root"]},"Action":"s3:*","Resource":"arn:aws:s3:::b/*"},
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::2:root"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*","Condition":{"StringEquals":{"aws:SourceVpc":"vpc-1"}}},
+				{"Effect":"Deny","Principal":"*","Action":"s3:DeleteBucket","Resource":"arn:aws:s3:::b"}
+			]}`,
+			added:  []string{"s3:*"},
+			widens: true,
+		},
+		{
+			name: "resource narrowed",
+			policy: `{"Statement":[
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:root"},"Action":"s3:*","Resource":"arn:aws:s3:::b/*/logs"},
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::2:root"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*","Condition":{"StringEquals":{"aws:SourceVpc":"vpc-1"}}},
+				{"Effect":"Deny","Principal":"*","Action":"s3:DeleteBucket","Resource":"arn:aws:s3:::b"}
+			]}`,
+			added:   []string{"s3:*"},
+			removed: []string{"s3:*"},
+		},
+		{
+			name:    "not action exclusion removed",
+			old:     `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:root"},"NotAction":["s3:DeleteObject","s3:PutObject"],"Resource":"arn:aws:s3:::b/*"}]}`,
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:root"},"NotAction":"s3:PutObject","Resource":"arn:aws:s3:::b/*"}]}`,
+			removed: []string{"!s3:DeleteObject"},
+			widens:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			old := oldPolicy
+			if c.old != "" {
+				old = c.old
+			}
+
+			var p1, p2 Policy
+			if err := json.Unmarshal([]byte(old), &p1); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(c.policy), &p2); err != nil {
+				t.Fatal(err)
+			}
+
+			diff := DiffPolicies(p1, p2)
+
+			var added, removed, changed []string
+			for _, p := range diff.Added {
+				added = append(added, p.Action)
+			}
+			for _, p := range diff.Removed {
+				removed = append(removed, p.Action)
+			}
+			for _, c := range diff.ChangedConditions {
+				changed = append(changed, c.Action)
+			}
+
+			if !reflect.DeepEqual(added, c.added) || !reflect.DeepEqual(removed, c.removed) ||
+				!reflect.DeepEqual(changed, c.changed) || diff.WidensAccess != c.widens {
+				t.Errorf("DiffPolicies() = added %v removed %v changed %v widens %v, want %v %v %v %v",
+					added, removed, changed, diff.WidensAccess, c.added, c.removed, c.changed, c.widens)
+			}
+		})
+	}
+}
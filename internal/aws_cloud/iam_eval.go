@@ -0,0 +1,437 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Evaluation decisions, following the IAM policy evaluation logic.
+const (
+	DecisionAllow        = "allow"
+	DecisionExplicitDeny = "explicit_deny"
+	DecisionImplicitDeny = "implicit_deny"
+)
+
+// EvalRequest is the request a set of policies is evaluated against.
+type EvalRequest struct {
+	// Principal is an IAM ARN, an account ID, a service principal such as s3.amazonaws.com,
+	// or empty to ignore Principal elements as identity policies do.
+	Principal string
+	Action    string
+	// Resource may be empty for policies without resources, such as trust policies.
+	Resource string
+	// Context holds condition keys and their values, e.g. {"aws:SourceVpc": ["vpc-1"]}.
+	Context map[string][]string
+}
+
+// MatchedStatement identifies a statement that took part in a decision.
+type MatchedStatement struct {
+	Policy    int
+	Statement int
+	Sid       string
+	Effect    string
+}
+
+// EvalResult is the decision with the statements that led to it:
+// the matching Deny statements for an explicit deny, the matching Allow statements for an allow.
+type EvalResult struct {
+	Decision string
+	Matched  []MatchedStatement
+}
+
+// Sids returns the non empty Sids of the matched statements.
+func (r EvalResult) Sids() []string {
+	sids := []string{}
+	for _, m := range r.Matched {
+		if m.Sid != "" {
+			sids = append(sids, m.Sid)
+		}
+	}
+	return sids
+}
+
+// EvaluatePolicies evaluates the request offline: an explicit Deny in any policy wins,
+// otherwise any Allow allows, otherwise the request is implicitly denied.
+// Only the policies given are considered, there are no SCPs, boundaries or session policies.
+func EvaluatePolicies(policies []Policy, req EvalRequest) (EvalResult, error) {
+	var allows, denies []MatchedStatement
+
+	for p, policy := range policies {
+		for s, stmt := range policy.Statement {
+			matched, err := statementMatches(stmt, req)
+			if err != nil {
+				return EvalResult{}, fmt.Errorf("policy %d statement %d: %w", p+1, s+1, err)
+			}
+			if !matched {
+				continue
+			}
+
+			m := MatchedStatement{Policy: p, Statement: s, Sid: stmt.Sid, Effect: stmt.Effect}
+			switch stmt.Effect {
+			case "Deny":
+				denies = append(denies, m)
+			case "Allow":
+				allows = append(allows, m)
+			default:
+				return EvalResult{}, fmt.Errorf("policy %d statement %d: unsupported effect %q", p+1, s+1, stmt.Effect)
+			}
+		}
+	}
+
+	switch {
+	case len(denies) > 0:
+		return EvalResult{Decision: DecisionExplicitDeny, Matched: denies}, nil
+	case len(allows) > 0:
+		return EvalResult{Decision: DecisionAllow, Matched: allows}, nil
+	default:
+		return EvalResult{Decision: DecisionImplicitDeny, Matched: []MatchedStatement{}}, nil
+	}
+}
+
+func statementMatches(stmt Statement, req EvalRequest) (bool, error) {
+	if req.Principal != "" {
+		if stmt.Principal != nil && !principalMatches(*stmt.Principal, req.Principal) {
+			return false, nil
+		}
+		if stmt.NotPrincipal != nil && principalMatches(*stmt.NotPrincipal, req.Principal) {
+			return false, nil
+		}
+	}
+
+	if !stmt.Action.IsZero() && !anyWildcardMatches(stmt.Action.Values, req.Action, true, nil) {
+		return false, nil
+	}
+	if !stmt.NotAction.IsZero() && anyWildcardMatches(stmt.NotAction.Values, req.Action, true, nil) {
+		return false, nil
+	}
+
+	if req.Resource != "" {
+		if !stmt.Resource.IsZero() && !anyWildcardMatches(stmt.Resource.Values, req.Resource, false, req.Context) {
+			return false, nil
+		}
+		if !stmt.NotResource.IsZero() && anyWildcardMatches(stmt.NotResource.Values, req.Resource, false, req.Context) {
+			return false, nil
+		}
+	}
+
+	return conditionMatches(stmt.Condition, req.Context)
+}
+
+func anyWildcardMatches(patterns []string, value string, caseInsensitive bool, context map[string][]string) bool {
+	for _, pattern := range patterns {
+		if context != nil {
+			pattern = substitutePolicyVariables(pattern, context)
+		}
+		if MatchWildcard(pattern, value, caseInsensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// substitutePolicyVariables replaces ${key} with its single context value, and the ${*}, ${?} and ${$} escapes.
+// Variables without a single value are left as they are, so they match nothing but themselves.
+func substitutePolicyVariables(pattern string, context map[string][]string) string {
+	if !strings.Contains(pattern, "${") {
+		return pattern
+	}
+
+	var out strings.Builder
+	for {
+		start := strings.Index(pattern, "${")
+		if start < 0 {
+			out.WriteString(pattern)
+			return out.String()
+		}
+		end := strings.Index(pattern[start:], "}")
+		if end < 0 {
+			out.WriteString(pattern)
+			return out.String()
+		}
+
+		out.WriteString(pattern[:start])
+		name := pattern[start+2 : start+end]
+		switch name {
+		case "*", "?", "$":
+			// the escaped character then matches as a wildcard again, close enough for offline checks.
+			out.WriteString(name)
+		default:
+			if values, ok := lookupContext(context, name); ok && len(values) == 1 {
+				out.WriteString(values[0])
+			} else {
+				out.WriteString(pattern[start : start+end+1])
+			}
+		}
+		pattern = pattern[start+end+1:]
+	}
+}
+
+// principalMatches reports whether the principal element names the request principal.
+// An account ID or account root ARN names every principal of the account.
+func principalMatches(principal Principal, requestPrincipal string) bool {
+	if principal.Wildcard {
+		return true
+	}
+
+	for _, v := range principal.AWS.Values {
+		if v == "*" || v == requestPrincipal {
+			return true
+		}
+
+		account := v
+		if strings.HasPrefix(v, "arn:") && strings.HasSuffix(v, ":root") {
+			account = arnAccount(v)
+		}
+		if account != "" && (account == requestPrincipal || account == arnAccount(requestPrincipal)) {
+			return true
+		}
+	}
+
+	for _, values := range [][]string{principal.Service.Values, principal.Federated.Values, principal.CanonicalUser.Values} {
+		for _, v := range values {
+			if v == "*" || v == requestPrincipal {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// lookupContext finds a condition key, which IAM compares case insensitively.
+func lookupContext(context map[string][]string, key string) ([]string, bool) {
+	if values, ok := context[key]; ok {
+		return values, true
+	}
+	for k, values := range context {
+		if strings.EqualFold(k, key) {
+			return values, true
+		}
+	}
+	return nil, false
+}
+
+// conditionMatches reports whether every operator and key of the condition block holds.
+func conditionMatches(condition Condition, context map[string][]string) (bool, error) {
+	for operator, keys := range condition {
+		for key, values := range keys {
+			policyValues := make([]string, len(values.Values))
+			for i, v := range values.Values {
+				policyValues[i] = fmt.Sprint(v)
+			}
+
+			requestValues, present := lookupContext(context, key)
+			ok, err := evaluateOperator(operator, policyValues, requestValues, present)
+			if err != nil {
+				return false, fmt.Errorf("condition %s on %s: %w", operator, key, err)
+			}
+			if !ok {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// evaluateOperator applies one condition operator, including its ForAllValues/ForAnyValue
+// qualifier and IfExists suffix, to the policy and request values of a key.
+func evaluateOperator(operator string, policyValues []string, requestValues []string, present bool) (bool, error) {
+	qualifier := ""
+	base := operator
+	if i := strings.Index(operator, ":"); i >= 0 {
+		qualifier, base = operator[:i], operator[i+1:]
+	}
+
+	ifExists := strings.HasSuffix(base, "IfExists")
+	base = strings.TrimSuffix(base, "IfExists")
+
+	if base == "Null" {
+		if len(policyValues) == 0 {
+			return false, fmt.Errorf("no value given")
+		}
+		return strings.EqualFold(policyValues[0], "true") == !present, nil
+	}
+
+	match, negated, err := conditionOperator(base)
+	if err != nil {
+		return false, err
+	}
+
+	// tests one request value against the policy values, which are ORed.
+	single := func(requestValue string) (bool, error) {
+		for _, policyValue := range policyValues {
+			ok, err := match(policyValue, requestValue)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return !negated, nil
+			}
+		}
+		return negated, nil
+	}
+
+	switch qualifier {
+	case "ForAllValues":
+		// vacuously true without values.
+		for _, v := range requestValues {
+			if ok, err := single(v); err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case "ForAnyValue":
+		for _, v := range requestValues {
+			if ok, err := single(v); err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case "":
+	default:
+		return false, fmt.Errorf("unsupported qualifier %q", qualifier)
+	}
+
+	if !present {
+		return ifExists || negated, nil
+	}
+
+	if negated {
+		// none of the request values may match.
+		for _, v := range requestValues {
+			if ok, err := single(v); err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	for _, v := range requestValues {
+		if ok, err := single(v); err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// conditionOperator returns the comparison for a base operator and whether the operator negates it.
+func conditionOperator(operator string) (func(policyValue, requestValue string) (bool, error), bool, error) {
+	switch operator {
+	case "StringEquals", "StringNotEquals":
+		return func(p, r string) (bool, error) { return p == r, nil }, operator == "StringNotEquals", nil
+	case "StringEqualsIgnoreCase", "StringNotEqualsIgnoreCase":
+		return func(p, r string) (bool, error) { return strings.EqualFold(p, r), nil }, operator == "StringNotEqualsIgnoreCase", nil
+	case "StringLike", "StringNotLike":
+		return func(p, r string) (bool, error) { return MatchWildcard(p, r, false), nil }, operator == "StringNotLike", nil
+	case "ArnEquals", "ArnLike", "ArnNotEquals", "ArnNotLike":
+		return func(p, r string) (bool, error) { return MatchARN(p, r), nil }, strings.Contains(operator, "Not"), nil
+	case "Bool":
+		return func(p, r string) (bool, error) { return strings.EqualFold(p, r), nil }, false, nil
+	case "BinaryEquals":
+		return func(p, r string) (bool, error) {
+			pb, err := base64.StdEncoding.DecodeString(p)
+			if err != nil {
+				return false, fmt.Errorf("%q is not base64: %w", p, err)
+			}
+			rb, err := base64.StdEncoding.DecodeString(r)
+			if err != nil {
+				return false, nil
+			}
+			return bytes.Equal(pb, rb), nil
+		}, false, nil
+	case "IpAddress", "NotIpAddress":
+		return func(p, r string) (bool, error) {
+			if !strings.Contains(p, "/") {
+				p += "/32"
+				if strings.Contains(p, ":") {
+					p = strings.TrimSuffix(p, "/32") + "/128"
+				}
+			}
+			_, network, err := net.ParseCIDR(p)
+			if err != nil {
+				return false, err
+			}
+			ip := net.ParseIP(r)
+			return ip != nil && network.Contains(ip), nil
+		}, operator == "NotIpAddress", nil
+	}
+
+	if strings.HasPrefix(operator, "Numeric") {
+		compare, negated, err := comparison(strings.TrimPrefix(operator, "Numeric"))
+		if err != nil {
+			return nil, false, fmt.Errorf("unsupported operator %q", operator)
+		}
+		return func(p, r string) (bool, error) {
+			pn, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return false, fmt.Errorf("%q is not a number", p)
+			}
+			rn, err := strconv.ParseFloat(r, 64)
+			if err != nil {
+				return false, nil
+			}
+			return compare(rn - pn), nil
+		}, negated, nil
+	}
+
+	if strings.HasPrefix(operator, "Date") {
+		compare, negated, err := comparison(strings.TrimPrefix(operator, "Date"))
+		if err != nil {
+			return nil, false, fmt.Errorf("unsupported operator %q", operator)
+		}
+		return func(p, r string) (bool, error) {
+			pt, err := parseConditionDate(p)
+			if err != nil {
+				return false, err
+			}
+			rt, err := parseConditionDate(r)
+			if err != nil {
+				return false, nil
+			}
+			return compare(float64(rt.Sub(pt))), nil
+		}, negated, nil
+	}
+
+	return nil, false, fmt.Errorf("unsupported operator %q", operator)
+}
+
+// comparison maps the suffix of a Numeric or Date operator to a test on request minus policy value.
+func comparison(suffix string) (func(diff float64) bool, bool, error) {
+	switch suffix {
+	case "Equals":
+		return func(d float64) bool { return d == 0 }, false, nil
+	case "NotEquals":
+		return func(d float64) bool { return d == 0 }, true, nil
+	case "LessThan":
+		return func(d float64) bool { return d < 0 }, false, nil
+	case "LessThanEquals":
+		return func(d float64) bool { return d <= 0 }, false, nil
+	case "GreaterThan":
+		return func(d float64) bool { return d > 0 }, false, nil
+	case "GreaterThanEquals":
+		return func(d float64) bool { return d >= 0 }, false, nil
+	}
+	return nil, false, fmt.Errorf("unsupported comparison %q", suffix)
+}
+
+// parseConditionDate accepts the ISO 8601 forms IAM does, or epoch seconds.
+func parseConditionDate(value string) (time.Time, error) {
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(epoch, 0), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05Z0700", "2006-01-02T15:04Z07:00", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date", value)
+}
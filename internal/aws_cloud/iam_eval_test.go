@@ -0,0 +1,107 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestEvaluatePolicies(t *testing.T) {
+	bucketPolicy := `{"Version":"2012-10-17","Statement":[
+		{"Sid":"ReadFromVpc","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111122223333:root"},"Action":"s3:Get*","Resource":"arn:aws:s3:::b/${aws:username}/*","Condition":{"StringEquals":{"aws:SourceVpc":["vpc-1","vpc-2"]}}},
+		{"Sid":"DenyHTTP","Effect":"Deny","Principal":"*","Action":"s3:*","Resource":["arn:aws:s3:::b","arn:aws:s3:::b/*"],"Condition":{"Bool":{"aws:SecureTransport":"false"}}},
+		{"Sid":"DenyOthers","Effect":"Deny","Principal":"*","NotAction":["s3:Get*","s3:List*"],"NotResource":"arn:aws:s3:::b/public/*","Condition":{"StringNotEquals":{"aws:PrincipalAccount":"111122223333"}}}
+	]}`
+
+	var policy Policy
+	if err := json.Unmarshal([]byte(bucketPolicy), &policy); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	role := "arn:aws:iam::111122223333:role/app"
+	cases := []struct {
+		name     string
+		req      EvalRequest
+		decision string
+		sids     []string
+	}{
+		{
+			name:     "allowed from the vpc",
+			req:      EvalRequest{Principal: role, Action: "s3:GetObject", Resource: "arn:aws:s3:::b/alice/x", Context: map[string][]string{"aws:SourceVpc": {"vpc-2"}, "aws:SecureTransport": {"true"}, "aws:username": {"alice"}, "aws:PrincipalAccount": {"111122223333"}}},
+			decision: DecisionAllow,
+			sids:     []string{"ReadFromVpc"},
+		},
+		{
+			name:     "policy variable does not match",
+			req:      EvalRequest{Principal: role, Action: "s3:GetObject", Resource: "arn:aws:s3:::b/bob/x", Context: map[string][]string{"aws:SourceVpc": {"vpc-2"}, "aws:username": {"alice"}, "aws:PrincipalAccount": {"111122223333"}}},
+			decision: DecisionImplicitDeny,
+			sids:     []string{},
+		},
+		{
+			name:     "explicit deny wins",
+			req:      EvalRequest{Principal: role, Action: "s3:GetObject", Resource: "arn:aws:s3:::b/alice/x", Context: map[string][]string{"aws:SourceVpc": {"vpc-1"}, "aws:SecureTransport": {"false"}, "aws:username": {"alice"}, "aws:PrincipalAccount": {"111122223333"}}},
+			decision: DecisionExplicitDeny,
+			sids:     []string{"DenyHTTP"},
+		},
+		{
+			name:     "not action and missing key of a negated operator",
+			req:      EvalRequest{Principal: "arn:aws:iam::444455556666:root", Action: "s3:PutObject", Resource: "arn:aws:s3:::b/x"},
+			decision: DecisionExplicitDeny,
+			sids:     []string{"DenyOthers"},
+		},
+		{
+			name:     "other principal",
+			req:      EvalRequest{Principal: "arn:aws:iam::444455556666:role/app", Action: "s3:GetObject", Resource: "arn:aws:s3:::b/alice/x", Context: map[string][]string{"aws:SourceVpc": {"vpc-1"}, "aws:username": {"alice"}}},
+			decision: DecisionImplicitDeny,
+			sids:     []string{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := EvaluatePolicies([]Policy{policy}, c.req)
+			if err != nil {
+				t.Fatalf("EvaluatePolicies() error = %v", err)
+			}
+			if result.Decision != c.decision || !reflect.DeepEqual(result.Sids(), c.sids) {
+				t.Errorf("EvaluatePolicies() = %s %v, want %s %v", result.Decision, result.Sids(), c.decision, c.sids)
+			}
+		})
+	}
+}
+
+func TestEvaluateOperator(t *testing.T) {
+	cases := []struct {
+		operator string
+		policy   []string
+		request  []string
+		present  bool
+		want     bool
+	}{
+		{"StringLikeIfExists", []string{"a*"}, nil, false, true},
+		{"StringLike", []string{"a*"}, nil, false, false},
+		{"ForAllValues:StringEquals", []string{"a", "b"}, []string{"a", "b"}, true, true},
+		{"ForAllValues:StringEquals", []string{"a"}, []string{"a", "c"}, true, false},
+		{"ForAnyValue:StringEquals", []string{"a"}, []string{"c", "a"}, true, true},
+		{"NumericLessThanEquals", []string{"10"}, []string{"10"}, true, true},
+		{"DateGreaterThan", []string{"2020-01-01T00:00:00Z"}, []string{"1700000000"}, true, true},
+		{"IpAddress", []string{"10.0.0.0/8"}, []string{"10.1.2.3"}, true, true},
+		{"NotIpAddress", []string{"10.0.0.0/8"}, []string{"10.1.2.3"}, true, false},
+		{"Null", []string{"true"}, nil, false, true},
+		{"StringEqualsIgnoreCase", []string{"ABC"}, []string{"abc"}, true, true},
+	}
+
+	for _, c := range cases {
+		got, err := evaluateOperator(c.operator, c.policy, c.request, c.present)
+		if err != nil {
+			t.Errorf("evaluateOperator(%s) error = %v", c.operator, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("evaluateOperator(%s, %v, %v) = %v, want %v", c.operator, c.policy, c.request, got, c.want)
+		}
+	}
+}
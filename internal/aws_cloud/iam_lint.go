@@ -0,0 +1,167 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Policy types accepted by LintPolicy.
+const (
+	PolicyTypeAuto     = "auto"
+	PolicyTypeIdentity = "identity"
+	PolicyTypeBucket   = "bucket"
+	PolicyTypeKMS      = "kms"
+)
+
+// Finding severities.
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// Finding is a lint result. StatementIndex is zero based, or -1 for findings about the whole policy.
+type Finding struct {
+	Rule           string
+	Severity       string
+	StatementIndex int
+	Sid            string
+	Message        string
+}
+
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// LintPolicy runs offline security checks on a policy. Bucket and KMS key policy checks only run
+// for those policy types; with PolicyTypeAuto the type is guessed from the statements.
+func LintPolicy(policy Policy, policyType string) ([]Finding, error) {
+	switch policyType {
+	case PolicyTypeAuto:
+		policyType = detectPolicyType(policy)
+	case PolicyTypeIdentity, PolicyTypeBucket, PolicyTypeKMS:
+	default:
+		return nil, fmt.Errorf("unsupported policy type %q, expected one of %s, %s, %s or %s", policyType, PolicyTypeAuto, PolicyTypeIdentity, PolicyTypeBucket, PolicyTypeKMS)
+	}
+
+	findings := []Finding{}
+	add := func(rule, severity string, index int, message string) {
+		sid := ""
+		if index >= 0 {
+			sid = policy.Statement[index].Sid
+		}
+		findings = append(findings, Finding{Rule: rule, Severity: severity, StatementIndex: index, Sid: sid, Message: message})
+	}
+
+	for i, stmt := range policy.Statement {
+		if stmt.Effect != "Allow" {
+			continue
+		}
+
+		allResources := containsValue(stmt.Resource.Values, "*")
+
+		if containsValue(stmt.Action.Values, "*") && allResources {
+			add("wildcard_action_resource", SeverityHigh, i, `Allows every action ("*") on every resource ("*")`)
+		}
+
+		if isPublicPrincipal(stmt.Principal) && len(stmt.Condition) == 0 {
+			add("public_principal", SeverityHigh, i, `Allows any principal ("*") without conditions`)
+		}
+
+		if allResources && grantsAction(stmt, "iam:PassRole") {
+			add("passrole_wildcard", SeverityHigh, i, `Allows iam:PassRole on every resource ("*"), which lets the principal pass any role to a service`)
+		}
+	}
+
+	if policyType == PolicyTypeBucket && !deniesInsecureTransport(policy) {
+		add("missing_secure_transport", SeverityMedium, -1, `No Deny statement on aws:SecureTransport "false", so requests over plain HTTP are not rejected`)
+	}
+
+	if policyType == PolicyTypeKMS && !rootManagesKey(policy) {
+		add("kms_lockout_risk", SeverityHigh, -1, "The account root is not allowed to manage the key (kms:PutKeyPolicy); if the listed principals are removed, the key becomes unmanageable")
+	}
+
+	return findings, nil
+}
+
+func detectPolicyType(policy Policy) string {
+	for _, stmt := range policy.Statement {
+		if stmt.Principal == nil && stmt.NotPrincipal == nil {
+			continue
+		}
+		for _, action := range append(append([]string{}, stmt.Action.Values...), stmt.NotAction.Values...) {
+			if strings.HasPrefix(strings.ToLower(action), "kms:") {
+				return PolicyTypeKMS
+			}
+		}
+		for _, resource := range append(append([]string{}, stmt.Resource.Values...), stmt.NotResource.Values...) {
+			if strings.HasPrefix(resource, "arn:") && strings.Contains(resource, ":s3:::") {
+				return PolicyTypeBucket
+			}
+		}
+	}
+	return PolicyTypeIdentity
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isPublicPrincipal(principal *Principal) bool {
+	return principal != nil && (principal.Wildcard || containsValue(principal.AWS.Values, "*"))
+}
+
+// grantsAction reports whether the statement's Action or NotAction covers the action.
+func grantsAction(stmt Statement, action string) bool {
+	if !stmt.NotAction.IsZero() {
+		return !anyWildcardMatches(stmt.NotAction.Values, action, true, nil)
+	}
+	return anyWildcardMatches(stmt.Action.Values, action, true, nil)
+}
+
+// deniesInsecureTransport looks for the usual Deny on aws:SecureTransport being false.
+func deniesInsecureTransport(policy Policy) bool {
+	for _, stmt := range policy.Statement {
+		if stmt.Effect != "Deny" {
+			continue
+		}
+		for operator, keys := range stmt.Condition {
+			if !strings.HasPrefix(operator, "Bool") {
+				continue
+			}
+			for key, values := range keys {
+				if !strings.EqualFold(key, "aws:SecureTransport") {
+					continue
+				}
+				for _, v := range values.Values {
+					if strings.EqualFold(fmt.Sprint(v), "false") {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// rootManagesKey reports whether an unconditional Allow lets the account root put the key policy.
+func rootManagesKey(policy Policy) bool {
+	for _, stmt := range policy.Statement {
+		if stmt.Effect != "Allow" || stmt.Principal == nil || len(stmt.Condition) > 0 || !grantsAction(stmt, "kms:PutKeyPolicy") {
+			continue
+		}
+		for _, v := range stmt.Principal.AWS.Values {
+			if accountIDPattern.MatchString(v) || (strings.HasPrefix(v, "arn:") && strings.HasSuffix(v, ":root")) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestLintPolicy(t *testing.T) {
+	cases := []struct {
+		name       string
+		policy     string
+		policyType string
+		want       []string
+	}{
+		{
+			name:       "admin identity policy",
+			policy:     `{"Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`,
+			policyType: PolicyTypeAuto,
+			want:       []string{"wildcard_action_resource", "passrole_wildcard"},
+		},
+		{
+			name:       "public bucket without secure transport",
+			policy:     `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*"}]}`,
+			policyType: PolicyTypeAuto,
+			want:       []string{"public_principal", "missing_secure_transport"},
+		},
+		{
+			name:       "bucket denying http",
+			policy:     `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"*"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*","Condition":{"StringEquals":{"aws:PrincipalOrgID":"o-1"}}},{"Effect":"Deny","Principal":"*","Action":"s3:*","Resource":["arn:aws:s3:::b","arn:aws:s3:::b/*"],"Condition":{"Bool":{"aws:SecureTransport":false}}}]}`,
+			policyType: PolicyTypeBucket,
+			want:       []string{},
+		},
+		{
+			name:       "kms key without root",
+			policy:     `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111122223333:role/admin"},"Action":"kms:*","Resource":"*"}]}`,
+			policyType: PolicyTypeAuto,
+			want:       []string{"kms_lockout_risk"},
+		},
+		{
+			name:       "kms default key policy",
+			policy:     `{"Statement":[{"Sid":"Enable IAM User Permissions","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111122223333:root"},"Action":"kms:*","Resource":"*"}]}`,
+			policyType: PolicyTypeKMS,
+			want:       []string{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var policy Policy
+			if err := json.Unmarshal([]byte(c.policy), &policy); err != nil {
+				t.Fatal(err)
+			}
+
+			findings, err := LintPolicy(policy, c.policyType)
+			if err != nil {
+				t.Fatalf("LintPolicy() error = %v", err)
+			}
+
+			rules := []string{}
+			for _, f := range findings {
+				rules = append(rules, f.Rule)
+			}
+			if !reflect.DeepEqual(rules, c.want) {
+				t.Errorf("LintPolicy() = %v, want %v", rules, c.want)
+			}
+		})
+	}
+}
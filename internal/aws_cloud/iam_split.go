@@ -0,0 +1,159 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Policy size limits in characters, whitespace excluded.
+const (
+	ManagedPolicySizeLimit    = 6144
+	RoleInlinePolicySizeLimit = 10240
+	BucketPolicySizeLimit     = 20480
+)
+
+// PolicySizeLimits maps the limit kinds accepted by split_policy to their size.
+var PolicySizeLimits = map[string]int{
+	"managed":     ManagedPolicySizeLimit,
+	"role_inline": RoleInlinePolicySizeLimit,
+	"bucket":      BucketPolicySizeLimit,
+}
+
+// SplitPolicy bin-packs the statements of a policy into as few documents as it can, each of
+// which marshals to at most limit bytes. Statements too large on their own have their Action
+// list split across several statements. Packing is first fit decreasing with ties broken by
+// statement order, and each document keeps the original statement order, so the result is stable.
+func SplitPolicy(policy Policy, limit int) ([]Policy, error) {
+	empty, err := json.Marshal(Policy{Version: policy.Version, Id: policy.Id, Statement: []Statement{}})
+	if err != nil {
+		return nil, err
+	}
+	// room left for statements and the commas between them.
+	room := limit - len(empty)
+
+	type sized struct {
+		stmt  Statement
+		size  int
+		order int
+	}
+
+	sids := make(map[string]bool, len(policy.Statement))
+	for _, stmt := range policy.Statement {
+		sids[stmt.Sid] = true
+	}
+
+	var statements []sized
+	for _, stmt := range policy.Statement {
+		parts, err := splitStatement(stmt, room, sids)
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range parts {
+			encoded, _ := json.Marshal(part)
+			statements = append(statements, sized{stmt: part, size: len(encoded), order: len(statements)})
+		}
+	}
+
+	sort.SliceStable(statements, func(i, j int) bool {
+		return statements[i].size > statements[j].size
+	})
+
+	type bin struct {
+		statements []sized
+		used       int
+	}
+
+	var bins []*bin
+	for _, s := range statements {
+		placed := false
+		for _, b := range bins {
+			// a comma separates it from the statements already in the bin.
+			if b.used+1+s.size <= room {
+				b.statements = append(b.statements, s)
+				b.used += 1 + s.size
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			bins = append(bins, &bin{statements: []sized{s}, used: s.size})
+		}
+	}
+
+	for _, b := range bins {
+		sort.Slice(b.statements, func(i, j int) bool {
+			return b.statements[i].order < b.statements[j].order
+		})
+	}
+	// keep documents in the order of their first statement.
+	sort.SliceStable(bins, func(i, j int) bool {
+		return bins[i].statements[0].order < bins[j].statements[0].order
+	})
+
+	policies := make([]Policy, 0, len(bins))
+	for _, b := range bins {
+		doc := Policy{Version: policy.Version, Id: policy.Id, Statement: make([]Statement, 0, len(b.statements))}
+		for _, s := range b.statements {
+			doc.Statement = append(doc.Statement, s.stmt)
+		}
+		policies = append(policies, doc)
+	}
+
+	return policies, nil
+}
+
+// splitStatement splits the Action list of a statement larger than room into statements
+// that each fit, suffixing their Sids with numbers not among the Sids already used.
+func splitStatement(stmt Statement, room int, sids map[string]bool) ([]Statement, error) {
+	encoded, err := json.Marshal(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if len(encoded) <= room {
+		return []Statement{stmt}, nil
+	}
+
+	if len(stmt.Action.Values) < 2 {
+		return nil, fmt.Errorf("statement %q is %d characters and cannot be split to fit in %d", stmt.Sid, len(encoded), room)
+	}
+
+	var parts []Statement
+	var current []string
+	for _, action := range stmt.Action.Values {
+		candidate := stmt
+		candidate.Action = StringOrSlice{Values: append(append([]string{}, current...), action), list: true}
+		encoded, _ := json.Marshal(candidate)
+
+		if len(encoded) > room && len(current) > 0 {
+			parts = append(parts, actionPart(stmt, current, len(parts), sids))
+			current = nil
+		}
+		current = append(current, action)
+	}
+	parts = append(parts, actionPart(stmt, current, len(parts), sids))
+
+	for _, part := range parts {
+		if encoded, _ := json.Marshal(part); len(encoded) > room {
+			return nil, fmt.Errorf("statement %q cannot be split to fit in %d characters", stmt.Sid, room)
+		}
+	}
+
+	return parts, nil
+}
+
+func actionPart(stmt Statement, actions []string, n int, sids map[string]bool) Statement {
+	stmt.Action = NewStringOrSlice(actions...)
+	if stmt.Sid != "" && n > 0 {
+		sid := stmt.Sid
+		for suffix := n + 1; sids[sid]; suffix++ {
+			sid = fmt.Sprintf("%s%d", stmt.Sid, suffix)
+		}
+		sids[sid] = true
+		stmt.Sid = sid
+	}
+	return stmt
+}
@@ -0,0 +1,94 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSplitPolicy(t *testing.T) {
+	policy := Policy{Version: "2012-10-17"}
+	for i := 0; i < 40; i++ {
+		policy.Statement = append(policy.Statement, Statement{
+			Sid:      fmt.Sprintf("S%d", i),
+			Effect:   "Allow",
+			Action:   NewStringOrSlice(fmt.Sprintf("s3:GetObject%d", i)),
+			Resource: NewStringOrSlice(fmt.Sprintf("arn:aws:s3:::bucket-%d/*", i)),
+		})
+	}
+	var actions []string
+	for i := 0; i < 400; i++ {
+		actions = append(actions, fmt.Sprintf("ec2:DescribeSomething%d", i))
+	}
+	policy.Statement = append(policy.Statement, Statement{Sid: "Big", Effect: "Allow", Action: NewStringOrSlice(actions...), Resource: NewStringOrSlice("*")})
+
+	docs, err := SplitPolicy(policy, ManagedPolicySizeLimit)
+	if err != nil {
+		t.Fatalf("SplitPolicy() error = %v", err)
+	}
+
+	var total, sids int
+	for _, doc := range docs {
+		encoded, _ := json.Marshal(doc)
+		if len(encoded) > ManagedPolicySizeLimit {
+			t.Errorf("document is %d characters, over the limit", len(encoded))
+		}
+		total += len(encoded)
+		for _, stmt := range doc.Statement {
+			if stmt.Sid == "Big" || stmt.Sid == "Big2" || stmt.Sid == "Big3" {
+				sids++
+			}
+		}
+	}
+	if sids < 2 {
+		t.Errorf("expected the large statement to be split, got %d parts", sids)
+	}
+	if min := total/ManagedPolicySizeLimit + 1; len(docs) > min+1 {
+		t.Errorf("SplitPolicy() gave %d documents, expected about %d", len(docs), min)
+	}
+
+	again, _ := SplitPolicy(policy, ManagedPolicySizeLimit)
+	if !reflect.DeepEqual(docs, again) {
+		t.Error("SplitPolicy() is not deterministic")
+	}
+}
+
+func TestSplitPolicyTooLarge(t *testing.T) {
+	policy := Policy{Statement: []Statement{{Effect: "Allow", Action: NewStringOrSlice("s3:GetObject"), Resource: NewStringOrSlice(fmt.Sprintf("arn:aws:s3:::%0200d", 0))}}}
+	if _, err := SplitPolicy(policy, 100); err == nil {
+		t.Error("SplitPolicy() expected an error for a statement that cannot fit")
+	}
+}
+
+func TestSplitPolicySidSuffixes(t *testing.T) {
+	var actions []string
+	for i := 0; i < 400; i++ {
+		actions = append(actions, fmt.Sprintf("ec2:DescribeSomething%d", i))
+	}
+	policy := Policy{Statement: []Statement{
+		{Sid: "Read", Effect: "Allow", Action: NewStringOrSlice(actions...), Resource: NewStringOrSlice("*")},
+		{Sid: "Read2", Effect: "Allow", Action: NewStringOrSlice("s3:GetObject"), Resource: NewStringOrSlice("*")},
+	}}
+
+	docs, err := SplitPolicy(policy, ManagedPolicySizeLimit)
+	if err != nil {
+		t.Fatalf("SplitPolicy() error = %v", err)
+	}
+
+	seen := map[string]bool{}
+	for _, doc := range docs {
+		for _, stmt := range doc.Statement {
+			if seen[stmt.Sid] {
+				t.Errorf("SplitPolicy() gave Sid %s twice", stmt.Sid)
+			}
+			seen[stmt.Sid] = true
+		}
+	}
+	if !seen["Read"] || !seen["Read2"] || !seen["Read3"] {
+		t.Errorf("SplitPolicy() gave Sids %v, want Read, Read2 for the original statement and Read3 for the second part", seen)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"reflect"
+)
+
+// SubtractPolicies removes the statements of remove from base, the reverse of MergePolicies.
+// A base statement matches a removed one when their effects and conditions are the same and,
+// with MatchSid, their Sids too. A matched statement covered in full is dropped; when it is
+// covered in two of principal, action and resource, the removed values of the third are taken
+// out of it, and it is dropped once that list is empty. Wildcards cover the values they match.
+// Statements using NotPrincipal, NotAction or NotResource are only dropped when identical.
+func SubtractPolicies(base, remove Policy, opts MergeOptions) (Policy, error) {
+	opts.setDefaults()
+	if err := opts.validate(); err != nil {
+		return Policy{}, err
+	}
+
+	result := base
+	result.Statement = append([]Statement{}, base.Statement...)
+
+	for _, r := range remove.Statement {
+		kept := result.Statement[:0:0]
+		for _, b := range result.Statement {
+			if !subtractable(b, r, opts) {
+				kept = append(kept, b)
+				continue
+			}
+			if remaining, ok := subtractStatement(b, r); ok {
+				kept = append(kept, remaining)
+			}
+		}
+		result.Statement = kept
+	}
+
+	return result, nil
+}
+
+func subtractable(b, r Statement, opts MergeOptions) bool {
+	if b.Effect != r.Effect || !sameCondition(b.Condition, r.Condition) {
+		return false
+	}
+	return opts.Match != MatchSid || (b.Sid != "" && b.Sid == r.Sid)
+}
+
+// sameCondition compares condition blocks by their operators, keys and value sets.
+func sameCondition(cond1, cond2 Condition) bool {
+	if len(cond1) != len(cond2) {
+		return false
+	}
+	for operator, keys1 := range cond1 {
+		keys2, ok := cond2[operator]
+		if !ok || len(keys1) != len(keys2) {
+			return false
+		}
+		for key, values1 := range keys1 {
+			values2, ok := keys2[key]
+			if !ok || !sameConditionValues(values1, values2) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// subtractStatement returns what is left of b once r is removed, and false when nothing is.
+func subtractStatement(b, r Statement) (Statement, bool) {
+	if b.NotPrincipal != nil || r.NotPrincipal != nil || !b.NotAction.IsZero() || !r.NotAction.IsZero() ||
+		!b.NotResource.IsZero() || !r.NotResource.IsZero() {
+		bn, rn := NormalizePolicy(Policy{Statement: []Statement{b}}), NormalizePolicy(Policy{Statement: []Statement{r}})
+		// conditions were compared by subtractable already.
+		bn.Statement[0].Sid, rn.Statement[0].Sid = "", ""
+		bn.Statement[0].Condition, rn.Statement[0].Condition = nil, nil
+		return b, !reflect.DeepEqual(bn.Statement[0], rn.Statement[0])
+	}
+
+	bPrincipals, rPrincipals := principalValues(b.Principal, ""), principalValues(r.Principal, "")
+	principals := principalsCovered(rPrincipals, bPrincipals)
+	actions := valuesCovered(r.Action.Values, b.Action.Values, true)
+	resources := valuesCovered(r.Resource.Values, b.Resource.Values, false)
+
+	switch {
+	case principals && actions && resources:
+		return b, false
+	case principals && resources:
+		b.Action = withoutCovered(b.Action, r.Action.Values, true)
+		return b, len(b.Action.Values) > 0
+	case principals && actions:
+		b.Resource = withoutCovered(b.Resource, r.Resource.Values, false)
+		return b, len(b.Resource.Values) > 0
+	case actions && resources && b.Principal != nil && !b.Principal.Wildcard:
+		remaining := withoutPrincipals(*b.Principal, rPrincipals)
+		b.Principal = &remaining
+		return b, len(principalValues(b.Principal, "")) > 0
+	}
+
+	return b, true
+}
+
+// valuesCovered reports whether every value is matched by one of the patterns.
+// Two empty lists, as for statements without resources, cover each other.
+func valuesCovered(patterns, values []string, caseInsensitive bool) bool {
+	if len(values) == 0 {
+		return len(patterns) == 0
+	}
+	for _, v := range values {
+		if !anyWildcardMatches(patterns, v, caseInsensitive, nil) {
+			return false
+		}
+	}
+	return true
+}
+
+func principalsCovered(patterns, values []string) bool {
+	if containsValue(patterns, "*") {
+		return true
+	}
+	if len(values) == 0 {
+		return len(patterns) == 0
+	}
+	for _, v := range values {
+		if !containsValue(patterns, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// withoutCovered drops the values matched by one of the patterns.
+func withoutCovered(values StringOrSlice, patterns []string, caseInsensitive bool) StringOrSlice {
+	remaining := []string{}
+	for _, v := range values.Values {
+		if !anyWildcardMatches(patterns, v, caseInsensitive, nil) {
+			remaining = append(remaining, v)
+		}
+	}
+	return NewStringOrSlice(remaining...)
+}
+
+// withoutPrincipals drops principal values named in removed, given in principalValues form.
+func withoutPrincipals(principal Principal, removed []string) Principal {
+	drop := func(values StringOrSlice, kind string) StringOrSlice {
+		remaining := []string{}
+		for _, v := range values.Values {
+			if !containsValue(removed, kind+":"+v) {
+				remaining = append(remaining, v)
+			}
+		}
+		if len(remaining) == 0 {
+			return StringOrSlice{}
+		}
+		return NewStringOrSlice(remaining...)
+	}
+
+	return Principal{
+		AWS:           drop(principal.AWS, "AWS"),
+		Federated:     drop(principal.Federated, "Federated"),
+		Service:       drop(principal.Service, "Service"),
+		CanonicalUser: drop(principal.CanonicalUser, "CanonicalUser"),
+	}
+}
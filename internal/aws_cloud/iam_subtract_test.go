@@ -0,0 +1,69 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSubtractPolicies(t *testing.T) {
+	base := `{"Version":"2012-10-17","Statement":[` +
+		`{"Sid":"Read","Effect":"Allow","Principal":{"AWS":["arn:aws:iam::1:role/a","arn:aws:iam::1:role/b"]},"Action":["s3:GetObject","s3:ListBucket"],"Resource":["arn:aws:s3:::b","arn:aws:s3:::b/*"]},` +
+		`{"Sid":"Write","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:role/a"},"Action":["s3:PutObject","s3:DeleteObject"],"Resource":"arn:aws:s3:::b/*"},` +
+		`{"Sid":"TLS","Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::b/*","Condition":{"Bool":{"aws:SecureTransport":"false"}}}]}`
+
+	cases := []struct {
+		name   string
+		remove string
+		opts   MergeOptions
+		want   string
+	}{
+		{
+			name:   "drop actions of a partly matching statement",
+			remove: `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:role/a"},"Action":"s3:DeleteObject","Resource":"arn:aws:s3:::b/*"}]}`,
+			want: `{"Version":"2012-10-17","Statement":[` +
+				`{"Sid":"Read","Effect":"Allow","Principal":{"AWS":["arn:aws:iam::1:role/a","arn:aws:iam::1:role/b"]},"Action":["s3:GetObject","s3:ListBucket"],"Resource":["arn:aws:s3:::b","arn:aws:s3:::b/*"]},` +
+				`{"Sid":"Write","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:role/a"},"Action":"s3:PutObject","Resource":"arn:aws:s3:::b/*"},` +
+				`{"Sid":"TLS","Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::b/*","Condition":{"Bool":{"aws:SecureTransport":"false"}}}]}`,
+		},
+		{
+			name:   "drop a principal and a whole statement",
+			remove: `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:role/b"},"Action":"s3:*","Resource":"arn:aws:s3:::b*"},{"Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::b/*","Condition":{"Bool":{"aws:SecureTransport":false}}}]}`,
+			want: `{"Version":"2012-10-17","Statement":[` +
+				`{"Sid":"Read","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:role/a"},"Action":["s3:GetObject","s3:ListBucket"],"Resource":["arn:aws:s3:::b","arn:aws:s3:::b/*"]},` +
+				`{"Sid":"Write","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:role/a"},"Action":["s3:PutObject","s3:DeleteObject"],"Resource":"arn:aws:s3:::b/*"}]}`,
+		},
+		{
+			name:   "sid matching leaves other sids alone",
+			remove: `{"Statement":[{"Sid":"Read","Effect":"Allow","Principal":{"AWS":["arn:aws:iam::1:role/a","arn:aws:iam::1:role/b"]},"Action":"s3:*","Resource":"*"}]}`,
+			opts:   MergeOptions{Match: MatchSid},
+			want: `{"Version":"2012-10-17","Statement":[` +
+				`{"Sid":"Write","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:role/a"},"Action":["s3:PutObject","s3:DeleteObject"],"Resource":"arn:aws:s3:::b/*"},` +
+				`{"Sid":"TLS","Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::b/*","Condition":{"Bool":{"aws:SecureTransport":"false"}}}]}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var basePolicy, removePolicy Policy
+			if err := json.Unmarshal([]byte(base), &basePolicy); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(c.remove), &removePolicy); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := SubtractPolicies(basePolicy, removePolicy, c.opts)
+			if err != nil {
+				t.Fatalf("SubtractPolicies() error = %v", err)
+			}
+
+			out, _ := json.Marshal(result)
+			if string(out) != c.want {
+				t.Errorf("SubtractPolicies() = %s, want %s", out, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Output formats of SummarizePolicy.
+const (
+	SummaryFormatMarkdown = "markdown"
+	SummaryFormatText     = "text"
+)
+
+// accessLevelUnknown groups actions missing from the action catalog.
+const accessLevelUnknown = "Unknown"
+
+var accessLevelOrder = []string{
+	AccessLevelList, AccessLevelRead, AccessLevelWrite, AccessLevelPermissionsManagement, AccessLevelTagging, accessLevelUnknown,
+}
+
+// accessLevelBreadth ranks access levels from the narrowest to the widest.
+var accessLevelBreadth = []string{
+	AccessLevelList, AccessLevelRead, AccessLevelTagging, AccessLevelWrite, AccessLevelPermissionsManagement,
+}
+
+// summaryRow is one access level of a statement.
+type summaryRow struct {
+	level      string
+	actions    []string
+	resources  string
+	conditions string
+}
+
+// summaryGroup holds the rows of the statements sharing an effect and principal.
+type summaryGroup struct {
+	effect    string
+	principal string
+	rows      []summaryRow
+}
+
+// SummarizePolicy renders who can do what under the policy, grouped by effect and principal, with
+// actions listed by access level and conditions described in English.
+func SummarizePolicy(policy Policy, format string) (string, error) {
+	if format != SummaryFormatMarkdown && format != SummaryFormatText {
+		return "", fmt.Errorf("unsupported format %q, expected %s or %s", format, SummaryFormatMarkdown, SummaryFormatText)
+	}
+
+	var groups []*summaryGroup
+	index := make(map[string]*summaryGroup)
+
+	for _, stmt := range policy.Statement {
+		principal := describePrincipals(stmt)
+		key := stmt.Effect + "\x00" + principal
+
+		group, ok := index[key]
+		if !ok {
+			group = &summaryGroup{effect: stmt.Effect, principal: principal}
+			index[key] = group
+			groups = append(groups, group)
+		}
+
+		resources := describeResources(stmt)
+		conditions := describeConditions(stmt.Condition, stmt.Effect)
+
+		if len(stmt.NotAction.Values) > 0 {
+			group.rows = append(group.rows, summaryRow{level: "All actions except", actions: stmt.NotAction.Values, resources: resources, conditions: conditions})
+			continue
+		}
+
+		levels := actionsByAccessLevel(stmt.Action.Values)
+		for _, level := range accessLevelOrder {
+			if actions := levels[level]; len(actions) > 0 {
+				group.rows = append(group.rows, summaryRow{level: level, actions: actions, resources: resources, conditions: conditions})
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i, group := range groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		if format == SummaryFormatMarkdown {
+			fmt.Fprintf(&b, "### %s: %s\n\n", group.effect, markdownCell(group.principal))
+			b.WriteString("| Access level | Actions | Resources | Conditions |\n")
+			b.WriteString("|---|---|---|---|\n")
+			for _, row := range group.rows {
+				fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", row.level, markdownCell(strings.Join(row.actions, ", ")), markdownCell(row.resources), markdownCell(row.conditions))
+			}
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s: %s\n", group.effect, group.principal)
+		for _, row := range group.rows {
+			fmt.Fprintf(&b, "  %s: %s\n    on %s\n", row.level, strings.Join(row.actions, ", "), row.resources)
+			if row.conditions != "" {
+				fmt.Fprintf(&b, "    %s\n", row.conditions)
+			}
+		}
+	}
+
+	return b.String(), nil
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// actionsByAccessLevel groups actions by the access levels they grant. A wildcard is listed once, under
+// the widest level of the actions it matches in the catalog.
+func actionsByAccessLevel(actions []string) map[string][]string {
+	levels := make(map[string][]string)
+	for _, action := range actions {
+		level := accessLevelUnknown
+		if _, info, ok := LookupAction(action); ok {
+			level = info.AccessLevel
+		} else if strings.ContainsAny(action, "*?") {
+			widest := -1
+			for _, a := range catalogMatches(action) {
+				if _, info, ok := LookupAction(a); ok {
+					if breadth := slices.Index(accessLevelBreadth, info.AccessLevel); breadth > widest {
+						widest, level = breadth, info.AccessLevel
+					}
+				}
+			}
+		}
+
+		levels[level] = append(levels[level], action)
+	}
+	return levels
+}
+
+func describePrincipals(stmt Statement) string {
+	if stmt.NotPrincipal != nil {
+		return "everyone except " + describePrincipal(stmt.NotPrincipal)
+	}
+	if stmt.Principal == nil {
+		return "the identities the policy is attached to"
+	}
+	return describePrincipal(stmt.Principal)
+}
+
+func describePrincipal(p *Principal) string {
+	if p.Wildcard || (len(p.AWS.Values) == 1 && p.AWS.Values[0] == "*") {
+		return "everyone"
+	}
+
+	var parts []string
+	for _, kind := range []struct {
+		name   string
+		values StringOrSlice
+	}{
+		{"AWS", p.AWS},
+		{"Service", p.Service},
+		{"Federated", p.Federated},
+		{"CanonicalUser", p.CanonicalUser},
+	} {
+		if len(kind.values.Values) > 0 {
+			parts = append(parts, kind.name+" "+strings.Join(kind.values.Values, ", "))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func describeResources(stmt Statement) string {
+	switch {
+	case len(stmt.NotResource.Values) > 0:
+		return "all resources except " + strings.Join(stmt.NotResource.Values, ", ")
+	case len(stmt.Resource.Values) == 0:
+		return "-"
+	case len(stmt.Resource.Values) == 1 && stmt.Resource.Values[0] == "*":
+		return "all resources"
+	}
+	return strings.Join(stmt.Resource.Values, ", ")
+}
+
+// describeConditions phrases the condition block, prefixed with only for Allow and when for Deny statements.
+func describeConditions(condition Condition, effect string) string {
+	if len(condition) == 0 {
+		return ""
+	}
+
+	var phrases []string
+	for operator, keys := range condition {
+		for key, values := range keys {
+			strs := make([]string, len(values.Values))
+			for i, v := range values.Values {
+				strs[i] = fmt.Sprint(v)
+			}
+			phrases = append(phrases, describeCondition(operator, key, strs))
+		}
+	}
+	sort.Strings(phrases)
+
+	prefix := "only "
+	if effect == "Deny" {
+		prefix = "when "
+	}
+	return prefix + strings.Join(phrases, " and ")
+}
+
+// describeCondition phrases a single condition key, with wording for the well known keys.
+func describeCondition(operator, key string, values []string) string {
+	var qualifier string
+	if base, ok := strings.CutPrefix(operator, "ForAllValues:"); ok {
+		operator, qualifier = base, " (for all values)"
+	} else if base, ok := strings.CutPrefix(operator, "ForAnyValue:"); ok {
+		operator, qualifier = base, " (for any value)"
+	}
+	if base, ok := strings.CutSuffix(operator, "IfExists"); ok {
+		operator, qualifier = base, qualifier+" (if present)"
+	}
+
+	negated := strings.Contains(operator, "Not")
+	not := ""
+	if negated {
+		not = "not "
+	}
+	list := strings.Join(values, " or ")
+	truth := len(values) == 1 && strings.EqualFold(values[0], "true")
+
+	lower := strings.ToLower(key)
+	var phrase string
+	switch {
+	case operator == "Null":
+		if truth {
+			phrase = key + " is absent"
+		} else {
+			phrase = key + " is present"
+		}
+	case lower == "aws:securetransport" && operator == "Bool":
+		if truth {
+			phrase = "over TLS"
+		} else {
+			phrase = "not over TLS"
+		}
+	case lower == "aws:multifactorauthpresent" && operator == "Bool":
+		if truth {
+			phrase = "with MFA"
+		} else {
+			phrase = "without MFA"
+		}
+	case lower == "aws:sourcevpce":
+		phrase = not + "from " + list
+	case lower == "aws:sourcevpc":
+		phrase = not + "from VPC " + list
+	case lower == "aws:sourceip":
+		phrase = not + "from " + list
+	case lower == "aws:principalorgid":
+		phrase = "for principals " + not + "in organization " + list
+	case lower == "aws:sourceaccount":
+		phrase = not + "on behalf of account " + list
+	case lower == "aws:sourcearn":
+		phrase = not + "on behalf of " + list
+	case lower == "aws:requestedregion":
+		phrase = not + "in " + list
+	case lower == "sts:externalid":
+		phrase = "with external ID " + list
+	case strings.HasPrefix(lower, "aws:principaltag/"):
+		phrase = "for principals " + not + "tagged " + key[len("aws:PrincipalTag/"):] + "=" + list
+	case lower == "aws:currenttime" && strings.HasPrefix(operator, "DateGreaterThan"):
+		phrase = "after " + list
+	case lower == "aws:currenttime" && strings.HasPrefix(operator, "DateLessThan"):
+		phrase = "before " + list
+	default:
+		phrase = key + " " + operatorPhrase(operator) + " " + list
+	}
+
+	return phrase + qualifier
+}
+
+func operatorPhrase(operator string) string {
+	switch operator {
+	case "StringEquals", "StringEqualsIgnoreCase", "NumericEquals", "DateEquals", "ArnEquals", "BinaryEquals", "Bool", "IpAddress":
+		return "is"
+	case "StringNotEquals", "StringNotEqualsIgnoreCase", "NumericNotEquals", "DateNotEquals", "ArnNotEquals", "NotIpAddress":
+		return "is not"
+	case "StringLike", "ArnLike":
+		return "matches"
+	case "StringNotLike", "ArnNotLike":
+		return "does not match"
+	case "NumericLessThan", "DateLessThan":
+		return "is less than"
+	case "NumericLessThanEquals", "DateLessThanEquals":
+		return "is at most"
+	case "NumericGreaterThan", "DateGreaterThan":
+		return "is greater than"
+	case "NumericGreaterThanEquals", "DateGreaterThanEquals":
+		return "is at least"
+	}
+	return operator
+}
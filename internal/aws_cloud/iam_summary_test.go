@@ -0,0 +1,69 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestActionsByAccessLevel(t *testing.T) {
+	got := actionsByAccessLevel([]string{"sqs:Get*", "sqs:*Message", "kms:Decrypt", "sts:Tag*", "s3:List*", "ec2:RunInstances", "ec2:Describe*"})
+	want := map[string][]string{
+		AccessLevelRead:    {"sqs:Get*"},
+		AccessLevelWrite:   {"sqs:*Message", "kms:Decrypt"},
+		AccessLevelTagging: {"sts:Tag*"},
+		AccessLevelList:    {"s3:List*"},
+		accessLevelUnknown: {"ec2:RunInstances", "ec2:Describe*"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actionsByAccessLevel() = %v, want %v", got, want)
+	}
+}
+
+func TestSummarizePolicy(t *testing.T) {
+	var policy Policy
+	err := json.Unmarshal([]byte(`{"Version":"2012-10-17","Statement":[`+
+		`{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:role/app"},"Action":["s3:GetObject","s3:ListBucket"],"Resource":["arn:aws:s3:::b","arn:aws:s3:::b/*"],"Condition":{"StringEquals":{"aws:SourceVpce":"vpce-123"}}},`+
+		`{"Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::b/*","Condition":{"Bool":{"aws:SecureTransport":"false"}}},`+
+		`{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:role/app"},"Action":"kms:Decrypt","Resource":"*"}]}`), &policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := SummarizePolicy(policy, SummaryFormatMarkdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `### Allow: AWS arn:aws:iam::1:role/app
+
+| Access level | Actions | Resources | Conditions |
+|---|---|---|---|
+| List | s3:ListBucket | arn:aws:s3:::b, arn:aws:s3:::b/* | only from vpce-123 |
+| Read | s3:GetObject | arn:aws:s3:::b, arn:aws:s3:::b/* | only from vpce-123 |
+| Write | kms:Decrypt | all resources |  |
+
+### Deny: everyone
+
+| Access level | Actions | Resources | Conditions |
+|---|---|---|---|
+| Permissions management | s3:* | arn:aws:s3:::b/* | when not over TLS |
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	text, err := SummarizePolicy(policy, SummaryFormatText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Allow: AWS arn:aws:iam::1:role/app\n  List: s3:ListBucket\n    on arn:aws:s3:::b, arn:aws:s3:::b/*\n    only from vpce-123\n"; text[:len(want)] != want {
+		t.Errorf("unexpected text summary\n%s", text)
+	}
+
+	if _, err := SummarizePolicy(policy, "html"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
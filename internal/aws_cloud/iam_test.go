@@ -0,0 +1,300 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPolicyRoundTrip(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy string
+	}{
+		{
+			name:   "wildcard principal",
+			policy: `{"Version":"2012-10-17","Id":"bucket","Statement":[{"Sid":"Public","Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*"}]}`,
+		},
+		{
+			name:   "single statement object",
+			policy: `{"Version":"2012-10-17","Statement":{"Effect":"Deny","NotPrincipal":{"AWS":["arn:aws:iam::111122223333:root"]},"NotAction":["kms:Decrypt"],"NotResource":"*"}}`,
+		},
+		{
+			name:   "conditions keep their types",
+			policy: `{"Statement":[{"Effect":"Deny","Principal":{"AWS":"*","Service":["s3.amazonaws.com","sns.amazonaws.com"]},"Action":["s3:*"],"Resource":["arn:aws:s3:::b"],"Condition":{"Bool":{"aws:SecureTransport":false},"NumericLessThan":{"s3:max-keys":10000000000000001},"StringEquals":{"aws:SourceArn":["a","b"],"aws:SourceAccount":"1"},"Null":{"aws:TokenIssueTime":[]}}}]}`,
+		},
+		{
+			name:   "canonical user",
+			policy: `{"Version":"2008-10-17","Statement":[{"Effect":"Allow","Principal":{"CanonicalUser":"abc"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*"}]}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var policy Policy
+			if err := json.Unmarshal([]byte(c.policy), &policy); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			out, err := json.Marshal(policy)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			var want, got interface{}
+			_ = json.Unmarshal([]byte(c.policy), &want)
+			_ = json.Unmarshal(out, &got)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip = %s, want %s", out, c.policy)
+			}
+		})
+	}
+}
+
+func TestPrincipalRejectsStrings(t *testing.T) {
+	var policy Policy
+	err := json.Unmarshal([]byte(`{"Statement":[{"Effect":"Allow","Principal":"arn:aws:iam::1:root","Action":"*","Resource":"*"}]}`), &policy)
+	if err == nil {
+		t.Error("Unmarshal() expected an error for a non wildcard principal string")
+	}
+}
+
+func TestMergePoliciesConditions(t *testing.T) {
+	statement := func(action string, condition string) string {
+		return `{"Statement":[{"Effect":"Allow","Principal":{"Service":"sns.amazonaws.com"},"Action":"` + action + `","Resource":"arn:aws:sqs:us-east-1:1:q","Condition":` + condition + `}]}`
+	}
+
+	cases := []struct {
+		name      string
+		action    string
+		condition string
+		opts      MergeOptions
+		want      string
+	}{
+		{
+			name:      "values are unioned per operator and key",
+			action:    "sqs:SendMessage",
+			condition: `{"ArnEquals":{"aws:SourceArn":"arn:aws:sns:us-east-1:1:b"}}`,
+			want:      `{"Statement":[{"Effect":"Allow","Principal":{"Service":"sns.amazonaws.com"},"Action":"sqs:SendMessage","Resource":"arn:aws:sqs:us-east-1:1:q","Condition":{"ArnEquals":{"aws:SourceArn":["arn:aws:sns:us-east-1:1:a","arn:aws:sns:us-east-1:1:b"]}}}]}`,
+		},
+		{
+			name:      "different actions with different values stay separate",
+			condition: `{"ArnEquals":{"aws:SourceArn":"arn:aws:sns:us-east-1:1:b"}}`,
+			want:      `{"Statement":[{"Effect":"Allow","Principal":{"Service":"sns.amazonaws.com"},"Action":"sqs:SendMessage","Resource":"arn:aws:sqs:us-east-1:1:q","Condition":{"ArnEquals":{"aws:SourceArn":"arn:aws:sns:us-east-1:1:a"}}},{"Effect":"Allow","Principal":{"Service":"sns.amazonaws.com"},"Action":"sqs:ReceiveMessage","Resource":"arn:aws:sqs:us-east-1:1:q","Condition":{"ArnEquals":{"aws:SourceArn":"arn:aws:sns:us-east-1:1:b"}}}]}`,
+		},
+		{
+			name:      "identical conditions union actions",
+			condition: `{"ArnEquals":{"aws:SourceArn":"arn:aws:sns:us-east-1:1:a"}}`,
+			want:      `{"Statement":[{"Effect":"Allow","Principal":{"Service":"sns.amazonaws.com"},"Action":["sqs:ReceiveMessage","sqs:SendMessage"],"Resource":"arn:aws:sqs:us-east-1:1:q","Condition":{"ArnEquals":{"aws:SourceArn":"arn:aws:sns:us-east-1:1:a"}}}]}`,
+		},
+		{
+			name:      "different keys stay separate",
+			condition: `{"ArnEquals":{"aws:SourceOwner":"1"}}`,
+			want:      `{"Statement":[{"Effect":"Allow","Principal":{"Service":"sns.amazonaws.com"},"Action":"sqs:SendMessage","Resource":"arn:aws:sqs:us-east-1:1:q","Condition":{"ArnEquals":{"aws:SourceArn":"arn:aws:sns:us-east-1:1:a"}}},{"Effect":"Allow","Principal":{"Service":"sns.amazonaws.com"},"Action":"sqs:ReceiveMessage","Resource":"arn:aws:sqs:us-east-1:1:q","Condition":{"ArnEquals":{"aws:SourceOwner":"1"}}}]}`,
+		},
+		{
+			name:      "negated operators stay separate",
+			condition: `{"ArnNotEquals":{"aws:SourceArn":"arn:aws:sns:us-east-1:1:b"}}`,
+			want:      `{"Statement":[{"Effect":"Allow","Principal":{"Service":"sns.amazonaws.com"},"Action":"sqs:SendMessage","Resource":"arn:aws:sqs:us-east-1:1:q","Condition":{"ArnEquals":{"aws:SourceArn":"arn:aws:sns:us-east-1:1:a"}}},{"Effect":"Allow","Principal":{"Service":"sns.amazonaws.com"},"Action":"sqs:ReceiveMessage","Resource":"arn:aws:sqs:us-east-1:1:q","Condition":{"ArnNotEquals":{"aws:SourceArn":"arn:aws:sns:us-east-1:1:b"}}}]}`,
+		},
+		{
+			name:      "forced merge unions everything",
+			condition: `{"ArnEquals":{"aws:SourceOwner":"1"}}`,
+			opts:      MergeOptions{MergeIncompatibleConditions: true},
+			want:      `{"Statement":[{"Effect":"Allow","Principal":{"Service":"sns.amazonaws.com"},"Action":["sqs:ReceiveMessage","sqs:SendMessage"],"Resource":"arn:aws:sqs:us-east-1:1:q","Condition":{"ArnEquals":{"aws:SourceArn":"arn:aws:sns:us-east-1:1:a","aws:SourceOwner":"1"}}}]}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var policy1, policy2 Policy
+			_ = json.Unmarshal([]byte(statement("sqs:SendMessage", `{"ArnEquals":{"aws:SourceArn":"arn:aws:sns:us-east-1:1:a"}}`)), &policy1)
+			action := c.action
+			if action == "" {
+				action = "sqs:ReceiveMessage"
+			}
+			_ = json.Unmarshal([]byte(statement(action, c.condition)), &policy2)
+
+			merged, err := MergePolicies(c.opts, policy1, policy2)
+			if err != nil {
+				t.Fatalf("MergePolicies() error = %v", err)
+			}
+
+			out, _ := json.Marshal(merged)
+			if string(out) != c.want {
+				t.Errorf("MergePolicies() = %s, want %s", out, c.want)
+			}
+		})
+	}
+}
+
+// Merging these into one statement would allow GetObject from vpc-b on behalf of account 222.
+func TestMergePoliciesKeepsConditionCombinations(t *testing.T) {
+	var policy1, policy2 Policy
+	_ = json.Unmarshal([]byte(`{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*","Condition":{"StringEquals":{"aws:SourceVpc":"vpc-a","aws:SourceAccount":"111"}}}]}`), &policy1)
+	_ = json.Unmarshal([]byte(`{"Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:DeleteObject","Resource":"arn:aws:s3:::b/*","Condition":{"StringEquals":{"aws:SourceVpc":"vpc-b","aws:SourceAccount":"222"}}}]}`), &policy2)
+
+	for _, actions := range [][2]string{{"s3:GetObject", "s3:DeleteObject"}, {"s3:GetObject", "s3:GetObject"}} {
+		policy2.Statement[0].Action = NewStringOrSlice(actions[1])
+
+		merged, err := MergePolicies(MergeOptions{}, policy1, policy2)
+		if err != nil {
+			t.Fatalf("MergePolicies() error = %v", err)
+		}
+		if len(merged.Statement) != 2 {
+			out, _ := json.Marshal(merged)
+			t.Errorf("%v: expected the statements to stay separate, got %s", actions, out)
+		}
+
+		result, err := EvaluatePolicies([]Policy{merged}, EvalRequest{
+			Principal: "arn:aws:iam::222:root",
+			Action:    "s3:GetObject",
+			Resource:  "arn:aws:s3:::b/x",
+			Context:   map[string][]string{"aws:SourceVpc": {"vpc-b"}, "aws:SourceAccount": {"222"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := actions[1] == "s3:GetObject"; (result.Decision == DecisionAllow) != want {
+			t.Errorf("%v: GetObject from vpc-b for 222 gave %s", actions, result.Decision)
+		}
+	}
+}
+
+func TestMergePoliciesListFormatting(t *testing.T) {
+	var policy1, policy2 Policy
+	_ = json.Unmarshal([]byte(`{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:root"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::a/*"}]}`), &policy1)
+	_ = json.Unmarshal([]byte(`{"Statement":[{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::1:root"]},"Action":"s3:PutObject","Resource":["arn:aws:s3:::a/*"]}]}`), &policy2)
+
+	merged, err := MergePolicies(MergeOptions{}, policy1, policy2)
+	if err != nil {
+		t.Fatalf("MergePolicies() error = %v", err)
+	}
+
+	out, _ := json.Marshal(merged)
+	want := `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:root"},"Action":["s3:GetObject","s3:PutObject"],"Resource":"arn:aws:s3:::a/*"}]}`
+	if string(out) != want {
+		t.Errorf("MergePolicies() = %s, want %s", out, want)
+	}
+}
+
+func TestMergePoliciesSid(t *testing.T) {
+	policies := func(docs ...string) []Policy {
+		var out []Policy
+		for _, doc := range docs {
+			var policy Policy
+			_ = json.Unmarshal([]byte(doc), &policy)
+			out = append(out, policy)
+		}
+		return out
+	}
+
+	readBucket := `{"Statement":[{"Sid":"Read","Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::a/*"}]}`
+	readOther := `{"Statement":[{"Sid":"Read","Effect":"Allow","Principal":"*","Action":"s3:ListBucket","Resource":"arn:aws:s3:::a"}]}`
+	denyHTTP := `{"Statement":[{"Sid":"Read","Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"*","Condition":{"Bool":{"aws:SecureTransport":"false"}}}]}`
+
+	cases := []struct {
+		name    string
+		opts    MergeOptions
+		docs    []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "match by sid",
+			opts: MergeOptions{Match: MatchSid},
+			docs: []string{readBucket, readOther},
+			want: `{"Statement":[{"Sid":"Read","Effect":"Allow","Principal":"*","Action":["s3:GetObject","s3:ListBucket"],"Resource":["arn:aws:s3:::a","arn:aws:s3:::a/*"]}]}`,
+		},
+		{
+			name:    "clash is an error by default",
+			docs:    []string{readBucket, readOther},
+			wantErr: true,
+		},
+		{
+			name: "clash renamed",
+			opts: MergeOptions{OnSidConflict: SidConflictRename},
+			docs: []string{readBucket, readOther, denyHTTP},
+			want: `{"Statement":[{"Sid":"Read","Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::a/*"},{"Sid":"Read2","Effect":"Allow","Principal":"*","Action":"s3:ListBucket","Resource":"arn:aws:s3:::a"},{"Sid":"Read3","Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"*","Condition":{"Bool":{"aws:SecureTransport":"false"}}}]}`,
+		},
+		{
+			name: "clash overridden",
+			opts: MergeOptions{OnSidConflict: SidConflictOverride},
+			docs: []string{readBucket, denyHTTP},
+			want: denyHTTP,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			merged, err := MergePolicies(c.opts, policies(c.docs...)...)
+			if c.wantErr {
+				if err == nil {
+					t.Error("MergePolicies() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MergePolicies() error = %v", err)
+			}
+
+			out, _ := json.Marshal(merged)
+			if string(out) != c.want {
+				t.Errorf("MergePolicies() = %s, want %s", out, c.want)
+			}
+		})
+	}
+}
+
+func TestMatchWildcard(t *testing.T) {
+	cases := []struct {
+		pattern, value string
+		want           bool
+	}{
+		{"s3:*", "s3:GetObject", true},
+		{"s3:Get*", "S3:getobject", true},
+		{"s3:Get?bject", "s3:GetObject", true},
+		{"s3:*Object", "s3:GetObjectAcl", false},
+		{"*", "", true},
+		{"arn:aws:s3:::b/*/x", "arn:aws:s3:::b/a/b/x", true},
+		// values holding wildcards themselves, as when one pattern is checked against another.
+		{"*", "*x", true},
+		{"s3:Get*", "s3:Get*Tagging", true},
+		{"s3:Get*Tagging", "s3:Get*", false},
+		{"s3:*Object", "s3:Get*", false},
+		{"arn:aws:s3:::b/*", "arn:aws:s3:::b/*/logs", true},
+		{"arn:aws:s3:::b/*/logs", "arn:aws:s3:::b/*", false},
+		{"s3:Get?bject", "s3:Get?bject", true},
+		{"s3:GetObject", "s3:Get?bject", false},
+		{"s3:*?", "s3:?", true},
+	}
+
+	for _, c := range cases {
+		if got := MatchWildcard(c.pattern, c.value, true); got != c.want {
+			t.Errorf("MatchWildcard(%q, %q) = %v, want %v", c.pattern, c.value, got, c.want)
+		}
+	}
+}
+
+func TestNormalizePolicy(t *testing.T) {
+	in := `{"Version":"2012-10-17","Statement":[` +
+		`{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::2:root","arn:aws:iam::1:root","arn:aws:iam::2:root"]},"Action":["s3:PutObject","s3:Get*","s3:GetObject","S3:GETOBJECT"],"Resource":["arn:aws:s3:::b/*"],"Condition":{"StringEquals":{"aws:SourceVpc":["vpc-2","vpc-1","vpc-2"]}}},` +
+		`{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::1:root","arn:aws:iam::2:root"]},"Action":["s3:Get*","s3:PutObject"],"Resource":"arn:aws:s3:::b/*","Condition":{"StringEquals":{"aws:SourceVpc":["vpc-1","vpc-2"]}}},` +
+		`{"Effect":"Deny","Principal":"*","NotAction":["iam:*","iam:PassRole"],"Resource":["*"]}]}`
+	want := `{"Version":"2012-10-17","Statement":[` +
+		`{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::1:root","arn:aws:iam::2:root"]},"Action":["s3:Get*","s3:PutObject"],"Resource":"arn:aws:s3:::b/*","Condition":{"StringEquals":{"aws:SourceVpc":["vpc-1","vpc-2"]}}},` +
+		`{"Effect":"Deny","Principal":"*","NotAction":"iam:*","Resource":"*"}]}`
+
+	var policy Policy
+	if err := json.Unmarshal([]byte(in), &policy); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	out, _ := json.Marshal(NormalizePolicy(policy))
+	if string(out) != want {
+		t.Errorf("NormalizePolicy() = %s, want %s", out, want)
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OIDC identity providers TrustPolicy knows the token claims of.
+const (
+	OIDCProviderGitHub = "github"
+	OIDCProviderGitLab = "gitlab"
+	OIDCProviderEKS    = "eks"
+)
+
+const (
+	githubOIDCHost = "token.actions.githubusercontent.com"
+	gitlabOIDCHost = "gitlab.com"
+	stsAudience    = "sts.amazonaws.com"
+)
+
+// TrustSpec describes who may assume a role.
+type TrustSpec struct {
+	// Partition of the generated ARNs, defaults to aws.
+	Partition    string
+	OIDC         []OIDCTrust
+	Services     []string
+	CrossAccount []CrossAccountTrust
+}
+
+// OIDCTrust lets tokens of an OIDC provider assume the role, filtered on their sub claim.
+type OIDCTrust struct {
+	// Provider is one of the OIDCProvider constants.
+	Provider string
+	// Account owning the IAM OIDC provider, used to build its ARN unless ProviderARN is set.
+	Account     string
+	ProviderARN string
+	// Audience expected in the aud claim, defaults to sts.amazonaws.com, or the GitLab URL for GitLab.
+	Audience string
+
+	// Repositories are GitHub owner/repo names, Projects GitLab project paths. Both may use wildcards.
+	Repositories []string
+	Projects     []string
+	// Host of a self managed GitLab, defaults to gitlab.com.
+	Host string
+	// Branches, Tags and Environments narrow the sub claim; without any, every ref is trusted.
+	// PullRequest trusts GitHub pull request workflows. Environments are GitHub only.
+	Branches     []string
+	Tags         []string
+	Environments []string
+	PullRequest  bool
+
+	// Issuer of the EKS cluster, e.g. https://oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE.
+	Issuer          string
+	Namespace       string
+	ServiceAccounts []string
+}
+
+// CrossAccountTrust lets other accounts or roles assume the role.
+type CrossAccountTrust struct {
+	// Principals are account IDs or IAM ARNs.
+	Principals  []string
+	ExternalIDs []string
+	RequireMFA  bool
+}
+
+var githubRepositoryPattern = regexp.MustCompile(`^[^/:\s]+/[^/:\s]+$`)
+
+// TrustPolicy builds an assume role policy from the spec.
+func TrustPolicy(spec TrustSpec) (Policy, error) {
+	partition := spec.Partition
+	if partition == "" {
+		partition = "aws"
+	}
+
+	policy := Policy{Version: "2012-10-17"}
+
+	for i, trust := range spec.OIDC {
+		stmt, err := oidcTrustStatement(partition, trust)
+		if err != nil {
+			return Policy{}, fmt.Errorf("oidc %d: %w", i+1, err)
+		}
+		policy.Statement = append(policy.Statement, stmt)
+	}
+
+	if len(spec.Services) > 0 {
+		for _, service := range spec.Services {
+			if !strings.Contains(service, ".amazonaws.com") {
+				return Policy{}, fmt.Errorf("invalid service principal %q, expected e.g. lambda.amazonaws.com", service)
+			}
+		}
+		policy.Statement = append(policy.Statement, Statement{
+			Effect:    "Allow",
+			Principal: &Principal{Service: NewStringOrSlice(spec.Services...)},
+			Action:    NewStringOrSlice("sts:AssumeRole"),
+		})
+	}
+
+	for i, trust := range spec.CrossAccount {
+		stmt, err := crossAccountTrustStatement(partition, trust)
+		if err != nil {
+			return Policy{}, fmt.Errorf("cross_account %d: %w", i+1, err)
+		}
+		policy.Statement = append(policy.Statement, stmt)
+	}
+
+	if len(policy.Statement) == 0 {
+		return Policy{}, fmt.Errorf("the spec trusts no one, set oidc, services or cross_account")
+	}
+
+	return policy, nil
+}
+
+func oidcTrustStatement(partition string, trust OIDCTrust) (Statement, error) {
+	var host, audience string
+	var subjects []string
+
+	switch trust.Provider {
+	case OIDCProviderGitHub:
+		host, audience = githubOIDCHost, stsAudience
+		if len(trust.Repositories) == 0 {
+			return Statement{}, fmt.Errorf("github trust needs at least one repository")
+		}
+		if len(trust.Projects) > 0 || trust.Issuer != "" {
+			return Statement{}, fmt.Errorf("github trust takes repositories, not projects or an issuer")
+		}
+		for _, repository := range trust.Repositories {
+			if !githubRepositoryPattern.MatchString(repository) {
+				return Statement{}, fmt.Errorf("invalid repository %q, expected owner/repo", repository)
+			}
+			subjects = append(subjects, githubSubjects(repository, trust)...)
+		}
+
+	case OIDCProviderGitLab:
+		host = trust.Host
+		if host == "" {
+			host = gitlabOIDCHost
+		}
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "https://"), "/")
+		audience = "https://" + host
+		if len(trust.Projects) == 0 {
+			return Statement{}, fmt.Errorf("gitlab trust needs at least one project")
+		}
+		if len(trust.Environments) > 0 || trust.PullRequest {
+			return Statement{}, fmt.Errorf("gitlab trust filters on branches and tags only")
+		}
+		for _, project := range trust.Projects {
+			subjects = append(subjects, gitlabSubjects(project, trust)...)
+		}
+
+	case OIDCProviderEKS:
+		host, audience = strings.TrimSuffix(strings.TrimPrefix(trust.Issuer, "https://"), "/"), stsAudience
+		if host == "" {
+			return Statement{}, fmt.Errorf("eks trust needs the cluster issuer")
+		}
+		if trust.Namespace == "" {
+			return Statement{}, fmt.Errorf("eks trust needs a namespace")
+		}
+		serviceAccounts := trust.ServiceAccounts
+		if len(serviceAccounts) == 0 {
+			serviceAccounts = []string{"*"}
+		}
+		for _, sa := range serviceAccounts {
+			subjects = append(subjects, "system:serviceaccount:"+trust.Namespace+":"+sa)
+		}
+
+	default:
+		return Statement{}, fmt.Errorf("unsupported provider %q, expected %s, %s or %s", trust.Provider, OIDCProviderGitHub, OIDCProviderGitLab, OIDCProviderEKS)
+	}
+
+	if trust.Audience != "" {
+		audience = trust.Audience
+	}
+
+	providerARN := trust.ProviderARN
+	if providerARN == "" {
+		if !arnAccountPattern.MatchString(trust.Account) {
+			return Statement{}, fmt.Errorf("set account to the 12 digit ID owning the OIDC provider, or provider_arn")
+		}
+		providerARN = ARN{Partition: partition, Service: "iam", Account: trust.Account, ResourceType: "oidc-provider", ResourceID: host}.String()
+	}
+
+	subOperator := "StringEquals"
+	for _, sub := range subjects {
+		if strings.ContainsAny(sub, "*?") {
+			subOperator = "StringLike"
+		}
+	}
+
+	condition := Condition{
+		"StringEquals": {host + ":aud": ConditionValues{Values: []interface{}{audience}}},
+	}
+	if subOperator == "StringEquals" {
+		condition["StringEquals"][host+":sub"] = conditionStrings(subjects)
+	} else {
+		condition[subOperator] = map[string]ConditionValues{host + ":sub": conditionStrings(subjects)}
+	}
+
+	return Statement{
+		Effect:    "Allow",
+		Principal: &Principal{Federated: NewStringOrSlice(providerARN)},
+		Action:    NewStringOrSlice("sts:AssumeRoleWithWebIdentity"),
+		Condition: condition,
+	}, nil
+}
+
+// githubSubjects returns the sub claims GitHub Actions issues for the filters of the repository.
+func githubSubjects(repository string, trust OIDCTrust) []string {
+	prefix := "repo:" + repository + ":"
+
+	var subjects []string
+	for _, branch := range trust.Branches {
+		subjects = append(subjects, prefix+"ref:refs/heads/"+branch)
+	}
+	for _, tag := range trust.Tags {
+		subjects = append(subjects, prefix+"ref:refs/tags/"+tag)
+	}
+	for _, environment := range trust.Environments {
+		subjects = append(subjects, prefix+"environment:"+environment)
+	}
+	if trust.PullRequest {
+		subjects = append(subjects, prefix+"pull_request")
+	}
+
+	if len(subjects) == 0 {
+		subjects = append(subjects, prefix+"*")
+	}
+	return subjects
+}
+
+// gitlabSubjects returns the sub claims GitLab CI issues for the filters of the project.
+func gitlabSubjects(project string, trust OIDCTrust) []string {
+	prefix := "project_path:" + project + ":"
+
+	var subjects []string
+	for _, branch := range trust.Branches {
+		subjects = append(subjects, prefix+"ref_type:branch:ref:"+branch)
+	}
+	for _, tag := range trust.Tags {
+		subjects = append(subjects, prefix+"ref_type:tag:ref:"+tag)
+	}
+
+	if len(subjects) == 0 {
+		subjects = append(subjects, prefix+"*")
+	}
+	return subjects
+}
+
+func crossAccountTrustStatement(partition string, trust CrossAccountTrust) (Statement, error) {
+	if len(trust.Principals) == 0 {
+		return Statement{}, fmt.Errorf("needs at least one principal")
+	}
+
+	principals := make([]string, 0, len(trust.Principals))
+	for _, p := range trust.Principals {
+		if arnAccountPattern.MatchString(p) && p != "aws" {
+			p = ARN{Partition: partition, Service: "iam", Account: p, ResourceID: "root"}.String()
+		} else if _, err := ParseARN(p); err != nil {
+			return Statement{}, fmt.Errorf("invalid principal %q, expected an account ID or an IAM ARN", p)
+		}
+		principals = append(principals, p)
+	}
+
+	stmt := Statement{
+		Effect:    "Allow",
+		Principal: &Principal{AWS: NewStringOrSlice(principals...)},
+		Action:    NewStringOrSlice("sts:AssumeRole"),
+	}
+
+	if len(trust.ExternalIDs) > 0 || trust.RequireMFA {
+		stmt.Condition = Condition{}
+	}
+	if len(trust.ExternalIDs) > 0 {
+		stmt.Condition["StringEquals"] = map[string]ConditionValues{"sts:ExternalId": conditionStrings(trust.ExternalIDs)}
+	}
+	if trust.RequireMFA {
+		stmt.Condition["Bool"] = map[string]ConditionValues{"aws:MultiFactorAuthPresent": {Values: []interface{}{"true"}}}
+	}
+
+	return stmt, nil
+}
+
+func conditionStrings(values []string) ConditionValues {
+	cv := ConditionValues{Values: make([]interface{}, len(values))}
+	for i, v := range values {
+		cv.Values[i] = v
+	}
+	return cv
+}
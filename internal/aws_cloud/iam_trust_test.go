@@ -0,0 +1,71 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTrustPolicy(t *testing.T) {
+	cases := []struct {
+		name string
+		spec TrustSpec
+		want string
+	}{
+		{
+			name: "github branch and environment",
+			spec: TrustSpec{OIDC: []OIDCTrust{{Provider: OIDCProviderGitHub, Account: "123456789012", Repositories: []string{"acme/app"}, Branches: []string{"main"}, Environments: []string{"prod"}}}},
+			want: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Federated":"arn:aws:iam::123456789012:oidc-provider/token.actions.githubusercontent.com"},"Action":"sts:AssumeRoleWithWebIdentity",` +
+				`"Condition":{"StringEquals":{"token.actions.githubusercontent.com:aud":"sts.amazonaws.com","token.actions.githubusercontent.com:sub":["repo:acme/app:ref:refs/heads/main","repo:acme/app:environment:prod"]}}}]}`,
+		},
+		{
+			name: "eks service accounts of a namespace",
+			spec: TrustSpec{OIDC: []OIDCTrust{{Provider: OIDCProviderEKS, Account: "123456789012", Issuer: "https://oidc.eks.us-east-1.amazonaws.com/id/ABC", Namespace: "apps"}}},
+			want: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Federated":"arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-east-1.amazonaws.com/id/ABC"},"Action":"sts:AssumeRoleWithWebIdentity",` +
+				`"Condition":{"StringEquals":{"oidc.eks.us-east-1.amazonaws.com/id/ABC:aud":"sts.amazonaws.com"},"StringLike":{"oidc.eks.us-east-1.amazonaws.com/id/ABC:sub":"system:serviceaccount:apps:*"}}}]}`,
+		},
+		{
+			name: "gitlab tags, services and cross account",
+			spec: TrustSpec{
+				OIDC:         []OIDCTrust{{Provider: OIDCProviderGitLab, Account: "123456789012", Projects: []string{"acme/app"}, Tags: []string{"v1"}}},
+				Services:     []string{"lambda.amazonaws.com"},
+				CrossAccount: []CrossAccountTrust{{Principals: []string{"210987654321"}, ExternalIDs: []string{"x-1"}}},
+			},
+			want: `{"Version":"2012-10-17","Statement":[` +
+				`{"Effect":"Allow","Principal":{"Federated":"arn:aws:iam::123456789012:oidc-provider/gitlab.com"},"Action":"sts:AssumeRoleWithWebIdentity","Condition":{"StringEquals":{"gitlab.com:aud":"https://gitlab.com","gitlab.com:sub":"project_path:acme/app:ref_type:tag:ref:v1"}}},` +
+				`{"Effect":"Allow","Principal":{"Service":"lambda.amazonaws.com"},"Action":"sts:AssumeRole"},` +
+				`{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::210987654321:root"},"Action":"sts:AssumeRole","Condition":{"StringEquals":{"sts:ExternalId":"x-1"}}}]}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			policy, err := TrustPolicy(c.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.Marshal(policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != c.want {
+				t.Errorf("got  %s\nwant %s", got, c.want)
+			}
+		})
+	}
+
+	invalid := []TrustSpec{
+		{},
+		{OIDC: []OIDCTrust{{Provider: OIDCProviderGitHub, Account: "123456789012", Repositories: []string{"app"}}}},
+		{OIDC: []OIDCTrust{{Provider: OIDCProviderGitHub, Repositories: []string{"acme/app"}}}},
+		{OIDC: []OIDCTrust{{Provider: OIDCProviderEKS, Account: "123456789012", Issuer: "oidc.eks.us-east-1.amazonaws.com/id/ABC"}}},
+		{CrossAccount: []CrossAccountTrust{{Principals: []string{"someone"}}}},
+	}
+	for i, spec := range invalid {
+		if _, err := TrustPolicy(spec); err == nil {
+			t.Errorf("spec %d: expected an error", i)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// ErrPolicyTargetNotFound is returned by a PolicyStore when the bucket or key no longer exists.
+var ErrPolicyTargetNotFound = errors.New("policy target not found")
+
+var errConcurrentPolicyChange = errors.New("policy changed concurrently")
+
+// PolicyStore reads and writes the resource policy of a single bucket or key.
+type PolicyStore interface {
+	// GetPolicy returns the current policy document, or an empty string when none is attached.
+	GetPolicy(ctx context.Context) (string, error)
+	PutPolicy(ctx context.Context, policy string) error
+	DeletePolicy(ctx context.Context) error
+}
+
+type bucketPolicyStore struct {
+	client *s3.Client
+	bucket string
+}
+
+// NewBucketPolicyStore returns a PolicyStore for the policy of an S3 bucket.
+func NewBucketPolicyStore(cfg aws.Config, bucket string) PolicyStore {
+	return &bucketPolicyStore{client: s3.NewFromConfig(cfg), bucket: bucket}
+}
+
+func (s *bucketPolicyStore) GetPolicy(ctx context.Context) (string, error) {
+	out, err := s.client.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String(s.bucket)})
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NoSuchBucketPolicy":
+			return "", nil
+		case "NoSuchBucket":
+			return "", fmt.Errorf("bucket %s: %w", s.bucket, ErrPolicyTargetNotFound)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get bucket policy for %s: %w", s.bucket, err)
+	}
+
+	return aws.ToString(out.Policy), nil
+}
+
+func (s *bucketPolicyStore) PutPolicy(ctx context.Context, policy string) error {
+	_, err := s.client.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{Bucket: aws.String(s.bucket), Policy: aws.String(policy)})
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "OperationAborted" {
+		return fmt.Errorf("bucket %s: %w", s.bucket, errConcurrentPolicyChange)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to put bucket policy for %s: %w", s.bucket, err)
+	}
+	return nil
+}
+
+func (s *bucketPolicyStore) DeletePolicy(ctx context.Context) error {
+	_, err := s.client.DeleteBucketPolicy(ctx, &s3.DeleteBucketPolicyInput{Bucket: aws.String(s.bucket)})
+	if err != nil {
+		return fmt.Errorf("failed to delete bucket policy for %s: %w", s.bucket, err)
+	}
+	return nil
+}
+
+type keyPolicyStore struct {
+	client *kms.Client
+	keyID  string
+}
+
+// NewKeyPolicyStore returns a PolicyStore for the default policy of a KMS key.
+func NewKeyPolicyStore(cfg aws.Config, keyID string) PolicyStore {
+	return &keyPolicyStore{client: kms.NewFromConfig(cfg), keyID: keyID}
+}
+
+func (s *keyPolicyStore) GetPolicy(ctx context.Context) (string, error) {
+	out, err := s.client.GetKeyPolicy(ctx, &kms.GetKeyPolicyInput{KeyId: aws.String(s.keyID), PolicyName: aws.String("default")})
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NotFoundException" {
+		return "", fmt.Errorf("key %s: %w", s.keyID, ErrPolicyTargetNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get KMS key policy for %s: %w", s.keyID, err)
+	}
+
+	return aws.ToString(out.Policy), nil
+}
+
+func (s *keyPolicyStore) PutPolicy(ctx context.Context, policy string) error {
+	_, err := s.client.PutKeyPolicy(ctx, &kms.PutKeyPolicyInput{KeyId: aws.String(s.keyID), PolicyName: aws.String("default"), Policy: aws.String(policy)})
+	if err != nil {
+		return fmt.Errorf("failed to put KMS key policy for %s: %w", s.keyID, err)
+	}
+	return nil
+}
+
+// DeletePolicy fails, as a key always has a policy and removing every statement would lock it out.
+func (s *keyPolicyStore) DeletePolicy(ctx context.Context) error {
+	return fmt.Errorf("refusing to remove the last statements of the KMS key policy for %s", s.keyID)
+}
+
+// ReplacePolicyStatements removes the statements whose Sid is owned from the policy and appends the given
+// statements. Every statement needs a unique Sid, and a Sid already in the policy must be owned.
+func ReplacePolicyStatements(policy Policy, statements []Statement, owned []string) (Policy, error) {
+	ownedSids := make(map[string]bool, len(owned))
+	for _, sid := range owned {
+		ownedSids[sid] = true
+	}
+
+	desired := make(map[string]bool, len(statements))
+	for i, s := range statements {
+		if s.Sid == "" {
+			return Policy{}, fmt.Errorf("statement %d has no Sid", i)
+		}
+		if desired[s.Sid] {
+			return Policy{}, fmt.Errorf("statement %d: duplicate Sid %q", i, s.Sid)
+		}
+		desired[s.Sid] = true
+	}
+
+	result := Policy{Version: policy.Version, Id: policy.Id}
+	if result.Version == "" {
+		result.Version = "2012-10-17"
+	}
+
+	for _, s := range policy.Statement {
+		if ownedSids[s.Sid] {
+			continue
+		}
+		if desired[s.Sid] {
+			return Policy{}, fmt.Errorf("Sid %q is already in the policy and not owned by this resource", s.Sid)
+		}
+		result.Statement = append(result.Statement, s)
+	}
+	result.Statement = append(result.Statement, statements...)
+
+	return result, nil
+}
+
+// OwnedStatements returns the statements of the policy whose Sid is owned, in policy order.
+func OwnedStatements(policy Policy, owned []string) []Statement {
+	ownedSids := make(map[string]bool, len(owned))
+	for _, sid := range owned {
+		ownedSids[sid] = true
+	}
+
+	var statements []Statement
+	for _, s := range policy.Statement {
+		if s.Sid != "" && ownedSids[s.Sid] {
+			statements = append(statements, s)
+		}
+	}
+	return statements
+}
+
+// ApplyPolicyStatements does a read-modify-write of the policy in the store, replacing the owned statements
+// with the given ones. Passing no statements removes the owned ones. The write is retried when the policy
+// changes between the read and the write, or when a concurrent writer drops the change right after it.
+func ApplyPolicyStatements(ctx context.Context, store PolicyStore, statements []Statement, owned []string) error {
+	desired := make([]string, 0, len(statements))
+	for _, s := range statements {
+		desired = append(desired, s.Sid)
+	}
+
+	const maxAttempts = 5
+	delay := 500 * time.Millisecond
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+
+			// an earlier attempt may have written the desired statements already.
+			owned = append(owned, desired...)
+		}
+
+		err = applyPolicyStatementsOnce(ctx, store, statements, owned, desired)
+		if !errors.Is(err, errConcurrentPolicyChange) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("gave up updating the policy after %d attempts: %w", maxAttempts, err)
+}
+
+func applyPolicyStatementsOnce(ctx context.Context, store PolicyStore, statements []Statement, owned []string, desired []string) error {
+	current, err := store.GetPolicy(ctx)
+	if err != nil {
+		return err
+	}
+
+	policy, err := parseStoredPolicy(current)
+	if err != nil {
+		return err
+	}
+
+	next, err := ReplacePolicyStatements(policy, statements, owned)
+	if err != nil {
+		return err
+	}
+
+	// the store has no conditional writes, so check the policy right before writing it.
+	latest, err := store.GetPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	if latest != current {
+		return errConcurrentPolicyChange
+	}
+
+	if len(next.Statement) == 0 {
+		err = store.DeletePolicy(ctx)
+	} else {
+		var document []byte
+		document, err = json.Marshal(next)
+		if err != nil {
+			return err
+		}
+		err = store.PutPolicy(ctx, string(document))
+	}
+	if err != nil {
+		return err
+	}
+
+	written, err := store.GetPolicy(ctx)
+	if err != nil {
+		return err
+	}
+
+	after, err := parseStoredPolicy(written)
+	if err != nil {
+		return err
+	}
+
+	// a writer racing with ours may have replaced the policy with its own read of the old one.
+	present := make(map[string]bool)
+	for _, s := range after.Statement {
+		present[s.Sid] = true
+	}
+
+	wanted := make(map[string]bool, len(desired))
+	for _, sid := range desired {
+		wanted[sid] = true
+		if !present[sid] {
+			return errConcurrentPolicyChange
+		}
+	}
+	for _, sid := range owned {
+		if present[sid] && !wanted[sid] {
+			return errConcurrentPolicyChange
+		}
+	}
+
+	return nil
+}
+
+func parseStoredPolicy(document string) (Policy, error) {
+	var policy Policy
+	if document == "" {
+		return policy, nil
+	}
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		return Policy{}, fmt.Errorf("unable to parse the current policy: %w", err)
+	}
+	return policy, nil
+}
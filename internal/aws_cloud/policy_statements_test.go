@@ -0,0 +1,91 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// memoryPolicyStore is a PolicyStore whose writes can be raced by another writer.
+type memoryPolicyStore struct {
+	policy string
+	// race, when set, replaces the policy once right after the next write.
+	race string
+}
+
+func (s *memoryPolicyStore) GetPolicy(context.Context) (string, error) {
+	return s.policy, nil
+}
+
+func (s *memoryPolicyStore) PutPolicy(_ context.Context, policy string) error {
+	s.policy = policy
+	if s.race != "" {
+		s.policy, s.race = s.race, ""
+	}
+	return nil
+}
+
+func (s *memoryPolicyStore) DeletePolicy(context.Context) error {
+	s.policy = ""
+	return nil
+}
+
+func statementsOf(t *testing.T, document string) []Statement {
+	t.Helper()
+	var policy Policy
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		t.Fatal(err)
+	}
+	return policy.Statement
+}
+
+func sidsOf(statements []Statement) []string {
+	var sids []string
+	for _, s := range statements {
+		sids = append(sids, s.Sid)
+	}
+	return sids
+}
+
+func TestApplyPolicyStatements(t *testing.T) {
+	ctx := context.Background()
+	other := `{"Version":"2012-10-17","Statement":[{"Sid":"Other","Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"*"}]}`
+	mine := statementsOf(t, `{"Statement":[{"Sid":"MineA","Effect":"Allow","Principal":"*","Action":"s3:ListBucket","Resource":"*"},{"Sid":"MineB","Effect":"Allow","Principal":"*","Action":"s3:PutObject","Resource":"*"}]}`)
+
+	store := &memoryPolicyStore{policy: other, race: other}
+	if err := ApplyPolicyStatements(ctx, store, mine, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := sidsOf(statementsOf(t, store.policy)); len(got) != 3 || got[0] != "Other" || got[2] != "MineB" {
+		t.Fatalf("after a raced write got sids %v", got)
+	}
+
+	if err := ApplyPolicyStatements(ctx, store, mine[:1], []string{"MineA", "MineB"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := sidsOf(statementsOf(t, store.policy)); len(got) != 2 || got[1] != "MineA" {
+		t.Fatalf("after releasing MineB got sids %v", got)
+	}
+
+	if err := ApplyPolicyStatements(ctx, store, nil, []string{"MineA"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := sidsOf(statementsOf(t, store.policy)); len(got) != 1 || got[0] != "Other" {
+		t.Fatalf("after destroy got sids %v", got)
+	}
+
+	taken := statementsOf(t, `{"Statement":[{"Sid":"Other","Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"*"}]}`)
+	if err := ApplyPolicyStatements(ctx, store, taken, nil); err == nil {
+		t.Fatal("expected an error for a Sid owned by someone else")
+	}
+
+	if err := ApplyPolicyStatements(ctx, store, nil, []string{"Other"}); err != nil {
+		t.Fatal(err)
+	}
+	if store.policy != "" {
+		t.Fatalf("expected the policy to be deleted, got %s", store.policy)
+	}
+}
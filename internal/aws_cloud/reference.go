@@ -0,0 +1,212 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Reference is a service::id::region reference as understood by ParseStr, optionally followed by
+// ?selector=value pairs and a #key, e.g. secret::db/creds::us-east-1?version_stage=AWSPREVIOUS#username.
+type Reference struct {
+	Service string
+	ID      string
+	Region  string
+	// Selectors pick a version of the referenced value, their names depend on the service.
+	Selectors map[string]string
+	// Key selects a top level key of a JSON object value.
+	Key string
+}
+
+// ParseReference parses a reference, the region, selectors and key being optional.
+func ParseReference(s string) (Reference, error) {
+	rest, key, hasKey := strings.Cut(s, "#")
+	if hasKey && key == "" {
+		return Reference{}, fmt.Errorf("invalid reference %q, # must be followed by a key", s)
+	}
+
+	rest, query, hasQuery := strings.Cut(rest, "?")
+
+	parsed := ParseStr(rest)
+	if parsed.Service == "" || parsed.ID == "" {
+		return Reference{}, fmt.Errorf("invalid reference %q, expected service::id::region", s)
+	}
+
+	ref := Reference{Service: parsed.Service, ID: parsed.ID, Region: parsed.Region, Key: key}
+	if hasQuery {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return Reference{}, fmt.Errorf("invalid selectors in reference %q: %w", s, err)
+		}
+		ref.Selectors = make(map[string]string, len(values))
+		for name, v := range values {
+			if len(v) != 1 || v[0] == "" {
+				return Reference{}, fmt.Errorf("selector %s of reference %q needs a single value", name, s)
+			}
+			ref.Selectors[name] = v[0]
+		}
+	}
+
+	return ref, nil
+}
+
+// CheckSelectors returns an error naming the first selector of the reference that is not allowed.
+func (r Reference) CheckSelectors(allowed ...string) error {
+	for name := range r.Selectors {
+		if !slices.Contains(allowed, name) {
+			if len(allowed) == 0 {
+				return fmt.Errorf("%s references take no selectors, got %s", r.Service, name)
+			}
+			return fmt.Errorf("unsupported %s selector %s, expected %s", r.Service, name, strings.Join(allowed, " or "))
+		}
+	}
+	return nil
+}
+
+// Select returns the value of the reference key in value, or value itself when the reference has no key.
+func (r Reference) Select(value any) (any, error) {
+	if r.Key == "" {
+		return value, nil
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot select key %s, %s %s is not a JSON object", r.Key, r.Service, r.ID)
+	}
+	selected, ok := object[r.Key]
+	if !ok {
+		return nil, fmt.Errorf("%s %s has no key %s", r.Service, r.ID, r.Key)
+	}
+	return selected, nil
+}
+
+// ReferenceFunc resolves a reference, returning false when the service is not one it knows.
+type ReferenceFunc func(ref Reference) (any, bool, error)
+
+// templatePart is either literal text or a reference.
+type templatePart struct {
+	text string
+	ref  *Reference
+}
+
+// Template is a string with ${service::id::region} references in it. $${ is a literal ${.
+type Template struct {
+	parts []templatePart
+}
+
+// ParseTemplate parses a string containing ${...} references. A string without any ${ that parses as a
+// reference is a reference as a whole, the way sub_data has always read them. ${...} without :: is kept as text.
+func ParseTemplate(s string) (Template, error) {
+	if !strings.Contains(s, "${") {
+		if !strings.Contains(s, "::") {
+			return Template{parts: []templatePart{{text: s}}}, nil
+		}
+		ref, err := ParseReference(s)
+		if err != nil {
+			return Template{parts: []templatePart{{text: s}}}, nil
+		}
+		return Template{parts: []templatePart{{text: s, ref: &ref}}}, nil
+	}
+
+	var t Template
+	var text strings.Builder
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "$${"):
+			text.WriteString("${")
+			i += 3
+		case strings.HasPrefix(s[i:], "${"):
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return Template{}, fmt.Errorf("unterminated ${ at offset %d", i)
+			}
+			raw, inner := s[i:i+end+1], s[i+2:i+end]
+			i += end + 1
+
+			if !strings.Contains(inner, "::") {
+				text.WriteString(raw)
+				continue
+			}
+			ref, err := ParseReference(inner)
+			if err != nil {
+				return Template{}, err
+			}
+			if text.Len() > 0 {
+				t.parts = append(t.parts, templatePart{text: text.String()})
+				text.Reset()
+			}
+			t.parts = append(t.parts, templatePart{text: raw, ref: &ref})
+		default:
+			text.WriteByte(s[i])
+			i++
+		}
+	}
+	if text.Len() > 0 {
+		t.parts = append(t.parts, templatePart{text: text.String()})
+	}
+
+	return t, nil
+}
+
+// HasReferences reports whether the template contains any reference.
+func (t Template) HasReferences() bool {
+	return slices.ContainsFunc(t.parts, func(p templatePart) bool { return p.ref != nil })
+}
+
+// References returns the references of the template in order.
+func (t Template) References() []Reference {
+	var refs []Reference
+	for _, part := range t.parts {
+		if part.ref != nil {
+			refs = append(refs, *part.ref)
+		}
+	}
+	return refs
+}
+
+// Render resolves the references of the template. A template that is a single reference renders to the
+// resolved value as is, so objects and lists are kept. Otherwise resolved strings are inserted as they are
+// and other values as JSON. References of services the resolver does not know are kept as written.
+func (t Template) Render(resolve ReferenceFunc) (any, error) {
+	if len(t.parts) == 1 && t.parts[0].ref != nil {
+		value, ok, err := resolve(*t.parts[0].ref)
+		if err != nil || !ok {
+			return t.parts[0].text, err
+		}
+		return value, nil
+	}
+
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.ref == nil {
+			b.WriteString(part.text)
+			continue
+		}
+
+		value, ok, err := resolve(*part.ref)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			b.WriteString(part.text)
+			continue
+		}
+
+		if s, isString := value.(string); isString {
+			b.WriteString(s)
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot insert %s %s into a string: %w", part.ref.Service, part.ref.ID, err)
+		}
+		b.Write(encoded)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,84 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	ref, err := ParseReference("secret::db/creds::us-east-1?version_stage=AWSPREVIOUS#username")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Reference{Service: "secret", ID: "db/creds", Region: "us-east-1", Selectors: map[string]string{"version_stage": "AWSPREVIOUS"}, Key: "username"}
+	if !reflect.DeepEqual(ref, want) {
+		t.Errorf("parsed %+v", ref)
+	}
+
+	if err := ref.CheckSelectors("version_id"); err == nil {
+		t.Error("expected version_stage to be rejected")
+	}
+
+	for _, invalid := range []string{"ssm::", "secret::db#", "ssm::p?version=1&version=2"} {
+		if _, err := ParseReference(invalid); err == nil {
+			t.Errorf("%s: expected an error", invalid)
+		}
+	}
+}
+
+func TestTemplateRender(t *testing.T) {
+	resolve := func(ref Reference) (any, bool, error) {
+		switch ref.Service {
+		case "secret":
+			return map[string]interface{}{"username": "app", "port": float64(5432)}, true, nil
+		case "fail":
+			return nil, true, errors.New("not found")
+		}
+		return nil, false, nil
+	}
+	render := func(s string) (any, error) {
+		template, err := ParseTemplate(s)
+		if err != nil {
+			return nil, err
+		}
+		return template.Render(func(ref Reference) (any, bool, error) {
+			value, ok, err := resolve(ref)
+			if err == nil && ok {
+				value, err = ref.Select(value)
+			}
+			return value, ok, err
+		})
+	}
+
+	cases := []struct {
+		in   string
+		want any
+	}{
+		{"postgres://${secret::db/creds::us-east-1#username}@host:${secret::db/creds#port}", "postgres://app@host:5432"},
+		{"secret::db/creds#username", "app"},
+		{"${secret::db/creds}", map[string]interface{}{"username": "app", "port": float64(5432)}},
+		{"literal $${secret::db/creds} and ${name}", "literal ${secret::db/creds} and ${name}"},
+		{"${other::x} stays", "${other::x} stays"},
+		{"plain::text::", "plain::text::"},
+	}
+	for _, c := range cases {
+		got, err := render(c.in)
+		if err != nil {
+			t.Errorf("%s: %v", c.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.in, got, c.want)
+		}
+	}
+
+	for _, invalid := range []string{"x ${fail::a} y", "${secret::db", "a ${secret::db/creds#missing}"} {
+		if _, err := render(invalid); err == nil {
+			t.Errorf("%s: expected an error", invalid)
+		}
+	}
+}
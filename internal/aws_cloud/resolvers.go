@@ -0,0 +1,437 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// Resolver fetches the values of the references of one service.
+type Resolver struct {
+	// Selectors lists the ?selector names the references may use.
+	Selectors []string
+	// Resolve returns the value of the reference, before its key is selected. Errors are reported as
+	// returned, so they should name what could not be resolved.
+	Resolve func(ctx context.Context, ref Reference) (any, error)
+	// Batch, when set, fetches many references of one region at once, returning the values it found by
+	// CacheKey. References it does not return are left to Resolve, which reports their errors.
+	Batch func(ctx context.Context, region string, refs []Reference) (map[string]any, error)
+	// Local resolvers read the provider host, their values are not cached.
+	Local bool
+}
+
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]Resolver{
+		"ssm":    {Selectors: []string{"version", "label"}, Resolve: resolveSSMParameter, Batch: batchSSMParameters},
+		"secret": {Selectors: []string{"version_stage", "version_id"}, Resolve: resolveSecret, Batch: batchSecrets},
+		"s3":     {Selectors: []string{"version_id"}, Resolve: resolveS3Object},
+		"kms":    {Resolve: resolveKMSCiphertext},
+		"sts":    {Resolve: resolveCallerIdentity},
+		"cfn":    {Resolve: resolveCloudFormationExport},
+		"env":    {Resolve: resolveEnv, Local: true},
+		"file":   {Resolve: resolveFile, Local: true},
+	}
+
+	// referenceCache holds resolved values by CacheKey for the lifetime of the provider process, so
+	// repeated sub_data calls of a plan fetch each reference once.
+	referenceCache sync.Map
+
+	// cloudFormationExports holds the exports of each region by name, listed once per provider process.
+	cloudFormationExports sync.Map
+)
+
+// Environment variables of the provider host allowing env:: and file:: references, as comma separated
+// patterns of the environment variable names and file paths they may read. Without them such references
+// fail, so a configuration cannot copy host secrets into plans and state unless the host opts in. Provider
+// functions run without the provider configuration, which is why this is not a provider attribute.
+const (
+	AllowedEnvReferencesEnv  = "AWSUTILS_ALLOWED_ENV_REFERENCES"
+	AllowedFileReferencesEnv = "AWSUTILS_ALLOWED_FILE_REFERENCES"
+)
+
+// CacheKey identifies the value of a reference, its key aside.
+func (r Reference) CacheKey() string {
+	selectors := make([]string, 0, len(r.Selectors))
+	for name, value := range r.Selectors {
+		selectors = append(selectors, name+"="+value)
+	}
+	sort.Strings(selectors)
+	return strings.Join([]string{r.Service, r.Region, r.ID, strings.Join(selectors, "&")}, "\x00")
+}
+
+func lookupResolver(service string) (Resolver, bool) {
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+	resolver, ok := resolvers[service]
+	return resolver, ok
+}
+
+// RegisterResolver adds or replaces the resolver of a service.
+func RegisterResolver(service string, resolver Resolver) {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	resolvers[service] = resolver
+}
+
+// ResolverServices returns the services references can be resolved for, sorted.
+func ResolverServices() []string {
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+
+	services := make([]string, 0, len(resolvers))
+	for service := range resolvers {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// ResolveReferences returns a ReferenceFunc resolving references with the registered resolvers,
+// and selecting their key. References of other services are reported as unknown.
+func ResolveReferences(ctx context.Context) ReferenceFunc {
+	return func(ref Reference) (any, bool, error) {
+		resolver, ok := lookupResolver(ref.Service)
+		if !ok {
+			return nil, false, nil
+		}
+
+		if err := ref.CheckSelectors(resolver.Selectors...); err != nil {
+			return nil, true, err
+		}
+
+		key := ref.CacheKey()
+		value, cached := referenceCache.Load(key)
+		if !cached {
+			var err error
+			value, err = resolver.Resolve(ctx, ref)
+			if err != nil {
+				return nil, true, err
+			}
+			if !resolver.Local {
+				referenceCache.Store(key, value)
+			}
+		}
+
+		value, err := ref.Select(value)
+		return value, true, err
+	}
+}
+
+// PrefetchReferences fetches the uncached references of resolvers that batch, grouped by service and
+// region, and caches the values. Failed batches are not reported: ResolveReferences fetches what is
+// still missing one by one and reports the errors of each reference.
+func PrefetchReferences(ctx context.Context, refs []Reference) {
+	groups := make(map[[2]string][]Reference)
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		resolver, ok := lookupResolver(ref.Service)
+		if !ok || resolver.Batch == nil || ref.CheckSelectors(resolver.Selectors...) != nil {
+			continue
+		}
+		key := ref.CacheKey()
+		if _, cached := referenceCache.Load(key); cached || seen[key] {
+			continue
+		}
+		seen[key] = true
+		group := [2]string{ref.Service, ref.Region}
+		groups[group] = append(groups[group], ref)
+	}
+
+	for group, refs := range groups {
+		resolver, _ := lookupResolver(group[0])
+		values, _ := resolver.Batch(ctx, group[1], refs)
+		for key, value := range values {
+			referenceCache.Store(key, value)
+		}
+	}
+}
+
+// batchSSMParameters fetches parameters with GetParameters, selectors included in their names.
+func batchSSMParameters(ctx context.Context, region string, refs []Reference) (map[string]any, error) {
+	cfg, err := regionConfig(&region)
+	if err != nil {
+		return nil, err
+	}
+	return batchParameters(ctx, ssm.NewFromConfig(cfg), refs)
+}
+
+func batchParameters(ctx context.Context, svc ssmParametersAPI, refs []Reference) (map[string]any, error) {
+	keys := make(map[string]string, len(refs))
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		// version and label together are rejected by Resolve.
+		if len(ref.Selectors) > 1 {
+			continue
+		}
+		name := ref.ID
+		for _, selector := range ref.Selectors {
+			name += ":" + selector
+		}
+		if _, dup := keys[name]; !dup {
+			names = append(names, name)
+		}
+		keys[name] = ref.CacheKey()
+	}
+
+	found, err := fetchSSMParameters(ctx, svc, names)
+	values := make(map[string]any, len(found))
+	for name, value := range found {
+		if key, ok := keys[name]; ok && value != nil && value != "" {
+			values[key] = value
+		}
+	}
+	return values, err
+}
+
+// batchSecrets fetches the current version of secrets with BatchGetSecretValue, which takes no selectors.
+func batchSecrets(ctx context.Context, region string, refs []Reference) (map[string]any, error) {
+	cfg, err := regionConfig(&region)
+	if err != nil {
+		return nil, fmt.Errorf("AWS Credentials Error, %v", err)
+	}
+	return batchSecretValues(ctx, secretsmanager.NewFromConfig(cfg), refs)
+}
+
+func batchSecretValues(ctx context.Context, svc secretsmanager.BatchGetSecretValueAPIClient, refs []Reference) (map[string]any, error) {
+	keys := make(map[string]string, len(refs))
+	var ids []string
+	for _, ref := range refs {
+		if len(ref.Selectors) > 0 {
+			continue
+		}
+		if _, dup := keys[ref.ID]; !dup {
+			ids = append(ids, ref.ID)
+		}
+		keys[ref.ID] = ref.CacheKey()
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	found, err := fetchSecrets(ctx, svc, ids)
+	values := make(map[string]any, len(found))
+	for id, value := range found {
+		if value != nil && value != "" {
+			values[keys[id]] = value
+		}
+	}
+	return values, err
+}
+
+func resolveSSMParameter(ctx context.Context, ref Reference) (any, error) {
+	// GetParameter selects versions and labels with a name:selector suffix.
+	name := ref.ID
+	if ref.Selectors["version"] != "" && ref.Selectors["label"] != "" {
+		return nil, fmt.Errorf("SSM parameter %s: set either version or label", ref.ID)
+	}
+	for _, selector := range ref.Selectors {
+		name += ":" + selector
+	}
+
+	value, err := FetchSSMParameter(ctx, name, &ref.Region)
+	if err == nil && (value == nil || value == "") {
+		err = fmt.Errorf("SSM parameter %s is empty", ref.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("SSM parameter %s not found: %w", ref.ID, err)
+	}
+	return value, nil
+}
+
+func resolveSecret(ctx context.Context, ref Reference) (any, error) {
+	value, err := FetchSecretVersion(ctx, ref.ID, &ref.Region, ref.Selectors["version_stage"], ref.Selectors["version_id"])
+	if err == nil && (value == nil || value == "") {
+		err = fmt.Errorf("secret %s is empty", ref.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("secret %s not found: %w", ref.ID, err)
+	}
+	return value, nil
+}
+
+// resolveS3Object reads s3::bucket/key, decoding the object as JSON when it is JSON.
+func resolveS3Object(ctx context.Context, ref Reference) (any, error) {
+	bucket, key, ok := strings.Cut(ref.ID, "/")
+	if !ok || bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid S3 reference %s, expected s3::bucket/key", ref.ID)
+	}
+
+	cfg, err := regionConfig(&ref.Region)
+	if err != nil {
+		return nil, fmt.Errorf("AWS Credentials Error, %v", err)
+	}
+
+	input := &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)}
+	if versionID := ref.Selectors["version_id"]; versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+	out, err := s3.NewFromConfig(cfg).GetObject(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("S3 object %s not found: %w", ref.ID, err)
+	}
+	defer out.Body.Close()
+
+	body, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading S3 object %s: %w", ref.ID, err)
+	}
+	return jsonOrText(body), nil
+}
+
+// resolveKMSCiphertext decrypts kms::ciphertext, the ciphertext being base64 encoded.
+func resolveKMSCiphertext(ctx context.Context, ref Reference) (any, error) {
+	blob, err := base64.StdEncoding.DecodeString(ref.ID)
+	if err != nil {
+		return nil, fmt.Errorf("KMS ciphertext is not valid base64: %w", err)
+	}
+
+	cfg, err := regionConfig(&ref.Region)
+	if err != nil {
+		return nil, fmt.Errorf("AWS Credentials Error, %v", err)
+	}
+
+	out, err := kms.NewFromConfig(cfg).Decrypt(ctx, &kms.DecryptInput{CiphertextBlob: blob})
+	if err != nil {
+		return nil, fmt.Errorf("KMS ciphertext could not be decrypted: %w", err)
+	}
+	return StringOrMap(string(out.Plaintext)), nil
+}
+
+// resolveCallerIdentity returns sts::account_id, sts::arn or sts::user_id of the caller.
+func resolveCallerIdentity(_ context.Context, ref Reference) (any, error) {
+	if ref.ID != "account_id" && ref.ID != "arn" && ref.ID != "user_id" {
+		return nil, fmt.Errorf("unsupported sts reference %s, expected account_id, arn or user_id", ref.ID)
+	}
+
+	cfg, err := regionConfig(&ref.Region)
+	if err != nil {
+		return nil, fmt.Errorf("AWS Credentials Error, %v", err)
+	}
+	identity, err := GetCallerIdentity(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("caller identity not available: %w", err)
+	}
+
+	switch ref.ID {
+	case "arn":
+		return aws.ToString(identity.Arn), nil
+	case "user_id":
+		return aws.ToString(identity.UserId), nil
+	}
+	return aws.ToString(identity.Account), nil
+}
+
+// resolveCloudFormationExport returns the value of the CloudFormation export named by the reference.
+func resolveCloudFormationExport(ctx context.Context, ref Reference) (any, error) {
+	exports, err := listCloudFormationExports(ctx, ref.Region)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := exports[ref.ID]
+	if !ok {
+		return nil, fmt.Errorf("CloudFormation export %s not found", ref.ID)
+	}
+	return value, nil
+}
+
+// listCloudFormationExports returns the exports of the region by name, listing them on first use.
+func listCloudFormationExports(ctx context.Context, region string) (map[string]string, error) {
+	if exports, ok := cloudFormationExports.Load(region); ok {
+		return exports.(map[string]string), nil
+	}
+
+	cfg, err := regionConfig(&region)
+	if err != nil {
+		return nil, fmt.Errorf("AWS Credentials Error, %v", err)
+	}
+
+	exports := make(map[string]string)
+	paginator := cloudformation.NewListExportsPaginator(cloudformation.NewFromConfig(cfg), &cloudformation.ListExportsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("CloudFormation exports could not be listed: %w", err)
+		}
+		for _, export := range page.Exports {
+			exports[aws.ToString(export.Name)] = aws.ToString(export.Value)
+		}
+	}
+
+	cloudFormationExports.Store(region, exports)
+	return exports, nil
+}
+
+func resolveEnv(_ context.Context, ref Reference) (any, error) {
+	if err := allowedLocalReference(AllowedEnvReferencesEnv, "environment variable "+ref.ID, ref.ID); err != nil {
+		return nil, err
+	}
+	value, ok := os.LookupEnv(ref.ID)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set", ref.ID)
+	}
+	return StringOrMap(value), nil
+}
+
+// resolveFile reads file::path, relative to the working directory, decoding the file as JSON when it is JSON.
+func resolveFile(_ context.Context, ref Reference) (any, error) {
+	path, err := filepath.Abs(ref.ID)
+	if err != nil {
+		return nil, fmt.Errorf("file %s could not be read: %w", ref.ID, err)
+	}
+	if err := allowedLocalReference(AllowedFileReferencesEnv, "file "+ref.ID, path); err != nil {
+		return nil, err
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("file %s could not be read: %w", ref.ID, err)
+	}
+	return jsonOrText(body), nil
+}
+
+// allowedLocalReference returns an error naming what was read unless value matches one of the patterns of
+// the environment variable. File patterns are made absolute, relative to the working directory, like the
+// paths they match.
+func allowedLocalReference(variable string, what string, value string) error {
+	for _, pattern := range strings.Split(os.Getenv(variable), ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if variable == AllowedFileReferencesEnv {
+			if abs, err := filepath.Abs(pattern); err == nil {
+				pattern = abs
+			}
+		}
+		if MatchWildcard(pattern, value, false) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not allowed, list it in the %s environment variable of the provider host", what, variable)
+}
+
+// jsonOrText decodes JSON content, or returns it as a string when it is not JSON.
+func jsonOrText(body []byte) any {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return string(body)
+	}
+	return value
+}
@@ -0,0 +1,341 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestResolveReferences(t *testing.T) {
+	t.Setenv("SUB_DATA_TEST", `{"user":"app"}`)
+	t.Setenv("SUB_DATA_SECRET", "s3cret")
+	t.Setenv(AllowedEnvReferencesEnv, "OTHER, SUB_DATA_TEST*")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"hosts":["a","b"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".key", []byte("key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(AllowedFileReferencesEnv, filepath.Join(dir, "*.json"))
+	RegisterResolver("test", Resolver{Resolve: func(_ context.Context, ref Reference) (any, error) { return "resolved " + ref.ID, nil }})
+
+	resolve := ResolveReferences(context.Background())
+	cases := []struct {
+		ref  string
+		want any
+	}{
+		{"env::SUB_DATA_TEST#user", "app"},
+		{"file::" + path + "#hosts", []any{"a", "b"}},
+		{"test::x", "resolved x"},
+	}
+	for _, c := range cases {
+		ref, err := ParseReference(c.ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok, err := resolve(ref)
+		if err != nil || !ok {
+			t.Errorf("%s: ok %v, error %v", c.ref, ok, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.ref, got, c.want)
+		}
+	}
+
+	for _, invalid := range []string{
+		"env::SUB_DATA_TEST_MISSING", "env::SUB_DATA_TEST?version=1", "file::" + path + ".missing.json",
+		// not allowed
+		"env::SUB_DATA_SECRET", "file::" + path + ".key", "file::" + dir + "/../" + filepath.Base(dir) + "/config.json.key",
+	} {
+		ref, _ := ParseReference(invalid)
+		if _, _, err := resolve(ref); err == nil {
+			t.Errorf("%s: expected an error", invalid)
+		}
+	}
+
+	if _, ok, _ := resolve(Reference{Service: "unknown", ID: "x"}); ok {
+		t.Error("expected unknown services not to be resolved")
+	}
+}
+
+func TestPrefetchReferences(t *testing.T) {
+	var batches [][]string
+	resolves := 0
+	RegisterResolver("batched", Resolver{
+		Resolve: func(_ context.Context, ref Reference) (any, error) {
+			resolves++
+			return "single " + ref.ID, nil
+		},
+		Batch: func(_ context.Context, region string, refs []Reference) (map[string]any, error) {
+			batch := []string{region}
+			values := make(map[string]any)
+			for _, ref := range refs {
+				batch = append(batch, ref.ID)
+				if ref.ID != "missing" {
+					values[ref.CacheKey()] = "batched " + ref.ID
+				}
+			}
+			batches = append(batches, batch)
+			return values, nil
+		},
+	})
+
+	var refs []Reference
+	for _, s := range []string{"batched::a::us-east-1", "batched::b::us-east-1", "batched::a::us-east-1", "batched::c::eu-west-1", "batched::missing::us-east-1"} {
+		ref, err := ParseReference(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs = append(refs, ref)
+	}
+	PrefetchReferences(context.Background(), refs)
+
+	sort.Slice(batches, func(i, j int) bool { return batches[i][0] < batches[j][0] })
+	if want := [][]string{{"eu-west-1", "c"}, {"us-east-1", "a", "b", "missing"}}; !reflect.DeepEqual(batches, want) {
+		t.Errorf("batches %v, want %v", batches, want)
+	}
+
+	resolve := ResolveReferences(context.Background())
+	for _, ref := range refs {
+		if _, _, err := resolve(ref); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if value, _, _ := resolve(refs[0]); value != "batched a" {
+		t.Errorf("resolved %v from the cache", value)
+	}
+	if resolves != 1 {
+		t.Errorf("Resolve called %d times, want once for the reference missing from the batch", resolves)
+	}
+
+	PrefetchReferences(context.Background(), refs)
+	if len(batches) != 2 {
+		t.Errorf("cached references were fetched again")
+	}
+}
+
+func TestResolveCloudFormationExport(t *testing.T) {
+	// exports listed earlier in the region are looked up without listing them again.
+	cloudFormationExports.Store("test-region-1", map[string]string{"network-VpcId": "vpc-1"})
+	t.Cleanup(func() { cloudFormationExports.Delete("test-region-1") })
+
+	got, err := resolveCloudFormationExport(context.Background(), Reference{Service: "cfn", ID: "network-VpcId", Region: "test-region-1"})
+	if err != nil || got != "vpc-1" {
+		t.Errorf("resolveCloudFormationExport() = %v, %v, want vpc-1", got, err)
+	}
+	if _, err := resolveCloudFormationExport(context.Background(), Reference{Service: "cfn", ID: "missing", Region: "test-region-1"}); err == nil {
+		t.Error("expected an error for a missing export")
+	}
+}
+
+func TestPrefetchReferencesFailedBatch(t *testing.T) {
+	var resolved []string
+	RegisterResolver("failing", Resolver{
+		Resolve: func(_ context.Context, ref Reference) (any, error) {
+			resolved = append(resolved, ref.ID)
+			return "single " + ref.ID, nil
+		},
+		// the first call of the batch succeeded, the second failed.
+		Batch: func(_ context.Context, _ string, refs []Reference) (map[string]any, error) {
+			return map[string]any{refs[0].CacheKey(): "batched " + refs[0].ID}, errors.New("throttled")
+		},
+	})
+
+	refs := []Reference{{Service: "failing", ID: "a"}, {Service: "failing", ID: "b"}}
+	PrefetchReferences(context.Background(), refs)
+
+	resolve := ResolveReferences(context.Background())
+	var got []any
+	for _, ref := range refs {
+		value, _, err := resolve(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, value)
+	}
+	if want := []any{"batched a", "single b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resolved %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(resolved, []string{"b"}) {
+		t.Errorf("Resolve called for %v, want only the reference the failed batch missed", resolved)
+	}
+}
+
+// fakeSSMParameters serves GetParameters from values keyed by name and selector, failing the call failAt.
+type fakeSSMParameters struct {
+	values map[string]string
+	calls  [][]string
+	failAt int
+}
+
+func (f *fakeSSMParameters) GetParameters(_ context.Context, in *ssm.GetParametersInput, _ ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	f.calls = append(f.calls, in.Names)
+	if len(f.calls) == f.failAt {
+		return nil, errors.New("throttled")
+	}
+
+	out := &ssm.GetParametersOutput{}
+	for _, requested := range in.Names {
+		value, ok := f.values[requested]
+		if !ok {
+			out.InvalidParameters = append(out.InvalidParameters, requested)
+			continue
+		}
+		name, selector, _ := strings.Cut(requested, ":")
+		parameter := ssmtypes.Parameter{Name: aws.String(name), Value: aws.String(value), Type: ssmtypes.ParameterTypeString}
+		if selector != "" {
+			parameter.Selector = aws.String(":" + selector)
+		}
+		out.Parameters = append(out.Parameters, parameter)
+	}
+	return out, nil
+}
+
+func TestBatchParameters(t *testing.T) {
+	api := &fakeSSMParameters{values: map[string]string{"/app/host": "db", "/app/host:2": "db-v2", "/app/host:live": "db-live"}}
+	refs := []Reference{
+		{Service: "ssm", ID: "/app/host"},
+		{Service: "ssm", ID: "/app/host", Selectors: map[string]string{"version": "2"}},
+		{Service: "ssm", ID: "/app/host", Selectors: map[string]string{"label": "live"}},
+		{Service: "ssm", ID: "/app/host"},
+		{Service: "ssm", ID: "/app/missing"},
+		// rejected by Resolve, never batched.
+		{Service: "ssm", ID: "/app/host", Selectors: map[string]string{"version": "2", "label": "live"}},
+	}
+
+	values, err := batchParameters(context.Background(), api, refs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{refs[0].CacheKey(): "db", refs[1].CacheKey(): "db-v2", refs[2].CacheKey(): "db-live"}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("batchParameters() = %v, want %v", values, want)
+	}
+	if want := [][]string{{"/app/host", "/app/host:2", "/app/host:live", "/app/missing"}}; !reflect.DeepEqual(api.calls, want) {
+		t.Errorf("GetParameters called with %v, want %v", api.calls, want)
+	}
+}
+
+func TestFetchSSMParametersChunks(t *testing.T) {
+	api := &fakeSSMParameters{values: map[string]string{}, failAt: 3}
+	var names []string
+	for i := 0; i < 25; i++ {
+		name := fmt.Sprintf("/p/%d", i)
+		names = append(names, name)
+		api.values[name] = name
+	}
+
+	values, err := fetchSSMParameters(context.Background(), api, names)
+	if err == nil {
+		t.Error("expected the error of the failed call")
+	}
+	if sizes := []int{len(api.calls[0]), len(api.calls[1]), len(api.calls[2])}; !reflect.DeepEqual(sizes, []int{10, 10, 5}) {
+		t.Errorf("GetParameters called with %v names, want 10, 10 and 5", sizes)
+	}
+	if len(values) != 20 {
+		t.Errorf("got %d values, want the 20 of the calls before the failure", len(values))
+	}
+}
+
+// fakeSecrets serves BatchGetSecretValue two secrets per page, secrets being known by name and ARN.
+type fakeSecrets struct {
+	values map[string]string
+	calls  [][]string
+	failAt int
+}
+
+func (f *fakeSecrets) BatchGetSecretValue(_ context.Context, in *secretsmanager.BatchGetSecretValueInput, _ ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error) {
+	if in.NextToken == nil {
+		f.calls = append(f.calls, in.SecretIdList)
+	}
+	if len(f.calls) == f.failAt {
+		return nil, errors.New("throttled")
+	}
+
+	start := 0
+	if in.NextToken != nil {
+		fmt.Sscan(*in.NextToken, &start)
+	}
+	end := min(start+2, len(in.SecretIdList))
+
+	out := &secretsmanager.BatchGetSecretValueOutput{}
+	for _, id := range in.SecretIdList[start:end] {
+		name := strings.TrimPrefix(id, "arn:aws:secretsmanager:us-east-1:1:secret:")
+		value, ok := f.values[name]
+		if !ok {
+			out.Errors = append(out.Errors, smtypes.APIErrorType{SecretId: aws.String(id), ErrorCode: aws.String("ResourceNotFoundException")})
+			continue
+		}
+		out.SecretValues = append(out.SecretValues, smtypes.SecretValueEntry{
+			Name:         aws.String(name),
+			ARN:          aws.String("arn:aws:secretsmanager:us-east-1:1:secret:" + name),
+			SecretString: aws.String(value),
+		})
+	}
+	if end < len(in.SecretIdList) {
+		out.NextToken = aws.String(fmt.Sprint(end))
+	}
+	return out, nil
+}
+
+func TestBatchSecretValues(t *testing.T) {
+	api := &fakeSecrets{values: map[string]string{"db": `{"user":"app"}`, "api": "token"}}
+	refs := []Reference{
+		{Service: "secret", ID: "db"},
+		{Service: "secret", ID: "arn:aws:secretsmanager:us-east-1:1:secret:api"},
+		{Service: "secret", ID: "missing"},
+		// selectors are left to Resolve.
+		{Service: "secret", ID: "db", Selectors: map[string]string{"version_stage": "AWSPREVIOUS"}},
+	}
+
+	values, err := batchSecretValues(context.Background(), api, refs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{refs[0].CacheKey(): map[string]interface{}{"user": "app"}, refs[1].CacheKey(): "token"}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("batchSecretValues() = %v, want %v", values, want)
+	}
+	if want := [][]string{{"db", "arn:aws:secretsmanager:us-east-1:1:secret:api", "missing"}}; !reflect.DeepEqual(api.calls, want) {
+		t.Errorf("BatchGetSecretValue called with %v, want %v", api.calls, want)
+	}
+}
+
+func TestFetchSecretsChunks(t *testing.T) {
+	api := &fakeSecrets{values: map[string]string{}, failAt: 3}
+	var ids []string
+	for i := 0; i < 45; i++ {
+		id := fmt.Sprintf("s%d", i)
+		ids = append(ids, id)
+		api.values[id] = id
+	}
+
+	values, err := fetchSecrets(context.Background(), api, ids)
+	if err == nil {
+		t.Error("expected the error of the failed call")
+	}
+	if sizes := []int{len(api.calls[0]), len(api.calls[1]), len(api.calls[2])}; !reflect.DeepEqual(sizes, []int{20, 20, 5}) {
+		t.Errorf("BatchGetSecretValue called with %v IDs, want 20, 20 and 5", sizes)
+	}
+	if len(values) != 40 {
+		t.Errorf("got %d values, want the 40 of the calls before the failure", len(values))
+	}
+}
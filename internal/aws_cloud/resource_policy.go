@@ -0,0 +1,185 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/opensearch"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
+)
+
+// ErrNoResourcePolicy is returned when the resource exists but has no policy attached.
+var ErrNoResourcePolicy = errors.New("no resource policy attached")
+
+// ResourcePolicyServices lists the ARN services GetResourcePolicy supports.
+var ResourcePolicyServices = []string{"ecr", "es", "events", "kms", "lambda", "s3", "secretsmanager", "sns", "sqs"}
+
+// GetResourcePolicy fetches the resource based policy of the resource named by the ARN, dispatching
+// on its service. The ARN region, when present, overrides the configured one.
+func GetResourcePolicy(ctx context.Context, cfg aws.Config, resourceARN string) (string, error) {
+	parsed, err := arn.Parse(resourceARN)
+	if err != nil {
+		return "", fmt.Errorf("invalid ARN %q: %w", resourceARN, err)
+	}
+	name, err := resourcePolicyName(parsed)
+	if err != nil {
+		return "", err
+	}
+
+	if parsed.Region != "" {
+		cfg = cfg.Copy()
+		cfg.Region = parsed.Region
+	}
+
+	var policy *string
+	switch parsed.Service {
+	case "sqs":
+		policy, err = getQueuePolicy(ctx, cfg, parsed)
+	case "sns":
+		var out *sns.GetTopicAttributesOutput
+		out, err = sns.NewFromConfig(cfg).GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{TopicArn: aws.String(name)})
+		if err == nil {
+			policy = attributePolicy(out.Attributes)
+		}
+	case "secretsmanager":
+		var out *secretsmanager.GetResourcePolicyOutput
+		out, err = secretsmanager.NewFromConfig(cfg).GetResourcePolicy(ctx, &secretsmanager.GetResourcePolicyInput{SecretId: aws.String(name)})
+		if err == nil {
+			policy = out.ResourcePolicy
+		}
+	case "ecr":
+		var out *ecr.GetRepositoryPolicyOutput
+		out, err = ecr.NewFromConfig(cfg).GetRepositoryPolicy(ctx, &ecr.GetRepositoryPolicyInput{
+			RepositoryName: aws.String(name),
+			RegistryId:     aws.String(parsed.AccountID),
+		})
+		var notFound *ecrtypes.RepositoryPolicyNotFoundException
+		if errors.As(err, &notFound) {
+			return "", fmt.Errorf("%s: %w", resourceARN, ErrNoResourcePolicy)
+		}
+		if err == nil {
+			policy = out.PolicyText
+		}
+	case "lambda":
+		var out *lambda.GetPolicyOutput
+		out, err = lambda.NewFromConfig(cfg).GetPolicy(ctx, &lambda.GetPolicyInput{FunctionName: aws.String(name)})
+		var notFound *lambdatypes.ResourceNotFoundException
+		if errors.As(err, &notFound) && functionExists(ctx, cfg, resourceARN) {
+			return "", fmt.Errorf("%s: %w", resourceARN, ErrNoResourcePolicy)
+		}
+		if err == nil {
+			policy = out.Policy
+		}
+	case "events":
+		var out *eventbridge.DescribeEventBusOutput
+		out, err = eventbridge.NewFromConfig(cfg).DescribeEventBus(ctx, &eventbridge.DescribeEventBusInput{Name: aws.String(name)})
+		if err == nil {
+			policy = out.Policy
+		}
+	case "es":
+		var out *opensearch.DescribeDomainOutput
+		out, err = opensearch.NewFromConfig(cfg).DescribeDomain(ctx, &opensearch.DescribeDomainInput{DomainName: aws.String(name)})
+		if err == nil && out.DomainStatus != nil {
+			policy = out.DomainStatus.AccessPolicies
+		}
+	case "s3":
+		var out *s3.GetBucketPolicyOutput
+		out, err = s3.NewFromConfig(cfg).GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String(name)})
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchBucketPolicy" {
+			return "", fmt.Errorf("%s: %w", resourceARN, ErrNoResourcePolicy)
+		}
+		if err == nil {
+			policy = out.Policy
+		}
+	case "kms":
+		var out *kms.GetKeyPolicyOutput
+		out, err = kms.NewFromConfig(cfg).GetKeyPolicy(ctx, &kms.GetKeyPolicyInput{KeyId: aws.String(name), PolicyName: aws.String("default")})
+		if err == nil {
+			policy = out.Policy
+		}
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to get resource policy for %s: %w", resourceARN, err)
+	}
+	if policy == nil || *policy == "" {
+		return "", fmt.Errorf("%s: %w", resourceARN, ErrNoResourcePolicy)
+	}
+
+	return *policy, nil
+}
+
+// resourcePolicyName returns the name the policy API of the ARN service takes: the repository, domain,
+// bucket or queue name for ECR, OpenSearch, S3 and SQS, and the ARN itself for the others.
+func resourcePolicyName(parsed arn.ARN) (string, error) {
+	switch parsed.Service {
+	case "ecr":
+		return strings.TrimPrefix(parsed.Resource, "repository/"), nil
+	case "es":
+		return strings.TrimPrefix(parsed.Resource, "domain/"), nil
+	case "s3":
+		return strings.SplitN(parsed.Resource, "/", 2)[0], nil
+	case "sqs":
+		return parsed.Resource, nil
+	case "events", "kms", "lambda", "secretsmanager", "sns":
+		return parsed.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported service %q in %s, expected one of %s", parsed.Service, parsed, strings.Join(ResourcePolicyServices, ", "))
+	}
+}
+
+// getQueuePolicy resolves the queue URL from the queue ARN and reads its Policy attribute.
+func getQueuePolicy(ctx context.Context, cfg aws.Config, parsed arn.ARN) (*string, error) {
+	client := sqs.NewFromConfig(cfg)
+
+	queue, err := client.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{
+		QueueName:              aws.String(parsed.Resource),
+		QueueOwnerAWSAccountId: aws.String(parsed.AccountID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       queue.QueueUrl,
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNamePolicy},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attributePolicy(out.Attributes), nil
+}
+
+func attributePolicy(attributes map[string]string) *string {
+	policy, ok := attributes["Policy"]
+	if !ok {
+		return nil
+	}
+	return &policy
+}
+
+// functionExists tells a function without a policy apart from a missing function, as GetPolicy fails alike for both.
+func functionExists(ctx context.Context, cfg aws.Config, functionARN string) bool {
+	_, err := lambda.NewFromConfig(cfg).GetFunctionConfiguration(ctx, &lambda.GetFunctionConfigurationInput{FunctionName: aws.String(functionARN)})
+	return err == nil
+}
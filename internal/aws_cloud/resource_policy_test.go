@@ -0,0 +1,61 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package awscloud
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+func TestResourcePolicyName(t *testing.T) {
+	cases := []struct {
+		arn  string
+		name string
+	}{
+		{"arn:aws:ecr:us-east-1:123456789012:repository/team/app", "team/app"},
+		{"arn:aws:es:us-east-1:123456789012:domain/logs", "logs"},
+		{"arn:aws:s3:::bucket", "bucket"},
+		{"arn:aws:s3:::bucket/path/key.txt", "bucket"},
+		{"arn:aws:sqs:us-east-1:123456789012:queue", "queue"},
+		{"arn:aws:sns:us-east-1:123456789012:topic", "arn:aws:sns:us-east-1:123456789012:topic"},
+		{"arn:aws:secretsmanager:us-east-1:123456789012:secret:db-AbCdEf", "arn:aws:secretsmanager:us-east-1:123456789012:secret:db-AbCdEf"},
+		{"arn:aws:lambda:us-east-1:123456789012:function:app", "arn:aws:lambda:us-east-1:123456789012:function:app"},
+		{"arn:aws:events:us-east-1:123456789012:event-bus/default", "arn:aws:events:us-east-1:123456789012:event-bus/default"},
+		{"arn:aws:kms:us-east-1:123456789012:key/1234abcd", "arn:aws:kms:us-east-1:123456789012:key/1234abcd"},
+	}
+
+	for _, c := range cases {
+		parsed, err := arn.Parse(c.arn)
+		if err != nil {
+			t.Fatalf("%s: %v", c.arn, err)
+		}
+		name, err := resourcePolicyName(parsed)
+		if err != nil {
+			t.Errorf("%s: %v", c.arn, err)
+			continue
+		}
+		if name != c.name {
+			t.Errorf("resourcePolicyName(%s) = %s, want %s", c.arn, name, c.name)
+		}
+	}
+}
+
+func TestResourcePolicyNameServices(t *testing.T) {
+	for _, service := range ResourcePolicyServices {
+		if _, err := resourcePolicyName(arn.ARN{Partition: "aws", Service: service, Resource: "r"}); err != nil {
+			t.Errorf("%s: %v", service, err)
+		}
+	}
+
+	for _, service := range []string{"dynamodb", "iam", ""} {
+		if slices.Contains(ResourcePolicyServices, service) {
+			t.Fatalf("%s is listed as supported", service)
+		}
+		if _, err := resourcePolicyName(arn.ARN{Partition: "aws", Service: service, Resource: "r"}); err == nil {
+			t.Errorf("%q: expected an unsupported service error", service)
+		}
+	}
+}
@@ -12,21 +12,60 @@ import (
 )
 
 // fetchSecret fetches a secret from AWS Secrets Manager by ID.
-func FetchSecret(secretID string, region *string) (interface{}, error) {
+func FetchSecret(ctx context.Context, secretID string, region *string) (interface{}, error) {
+	return FetchSecretVersion(ctx, secretID, region, "", "")
+}
+
+// FetchSecretVersion fetches a version of a secret by stage or version ID, the current version when both are empty.
+func FetchSecretVersion(ctx context.Context, secretID string, region *string, versionStage string, versionID string) (interface{}, error) {
 	// Create a Secrets Manager client
-	cfg, err := GetConfig(region, nil, nil)
+	cfg, err := regionConfig(region)
 	if err != nil {
 		return "", fmt.Errorf("AWS Credentials Error, %v", err)
 	}
 	svc := secretsmanager.NewFromConfig(cfg)
 
-	// Get the secret value
-	result, err := svc.GetSecretValue(context.TODO(), &secretsmanager.GetSecretValueInput{
+	input := &secretsmanager.GetSecretValueInput{
 		SecretId: aws.String(secretID),
-	})
+	}
+	if versionStage != "" {
+		input.VersionStage = aws.String(versionStage)
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+
+	// Get the secret value
+	result, err := svc.GetSecretValue(ctx, input)
 	if err != nil {
 		return "", fmt.Errorf("unable to retrieve secret, %v", err)
 	}
 
 	return StringOrMap(aws.ToString(result.SecretString)), nil
 }
+
+// fetchSecrets fetches the current version of secrets of one region with BatchGetSecretValue, 20 per call.
+// The values are returned by the ID they were requested with, secrets that are not found are missing, and
+// the values fetched before a call fails are returned with its error.
+func fetchSecrets(ctx context.Context, svc secretsmanager.BatchGetSecretValueAPIClient, secretIDs []string) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(secretIDs))
+	for start := 0; start < len(secretIDs); start += 20 {
+		batch := secretIDs[start:min(start+20, len(secretIDs))]
+		paginator := secretsmanager.NewBatchGetSecretValuePaginator(svc, &secretsmanager.BatchGetSecretValueInput{SecretIdList: batch})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return values, fmt.Errorf("unable to retrieve secrets, %v", err)
+			}
+			for _, secret := range page.SecretValues {
+				// secrets may be requested by name or ARN.
+				for _, id := range batch {
+					if id == aws.ToString(secret.Name) || id == aws.ToString(secret.ARN) {
+						values[id] = StringOrMap(aws.ToString(secret.SecretString))
+					}
+				}
+			}
+		}
+	}
+	return values, nil
+}
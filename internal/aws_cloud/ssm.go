@@ -10,10 +10,11 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
 )
 
-func getParameter(param string, region *string) (*ssm.GetParameterOutput, error) {
-	cfg, err := GetConfig(region, nil, nil)
+func getParameter(ctx context.Context, param string, region *string) (*ssm.GetParameterOutput, error) {
+	cfg, err := regionConfig(region)
 	if err != nil {
 		return nil, err
 	}
@@ -24,22 +25,51 @@ func getParameter(param string, region *string) (*ssm.GetParameterOutput, error)
 		WithDecryption: aws.Bool(true),
 	}
 
-	return svc.GetParameter(context.TODO(), input)
+	return svc.GetParameter(ctx, input)
 
 }
 
 // fetchSSMParameter fetches a parameter from AWS SSM Parameter Store by name.
-func FetchSSMParameter(paramName string, region *string) (interface{}, error) {
-	result, err := getParameter(paramName, region)
+func FetchSSMParameter(ctx context.Context, paramName string, region *string) (interface{}, error) {
+	result, err := getParameter(ctx, paramName, region)
 
 	if err != nil {
 		return "", fmt.Errorf("unable to retrieve parameter, %v", err)
 	}
 
+	return parameterValue(*result.Parameter), nil
+}
+
+func parameterValue(parameter types.Parameter) interface{} {
 	// if the parameter is a StringList, split it by comma and return it as a slice.
-	if result.Parameter.Type == "StringList" {
-		return strings.Split(aws.ToString(result.Parameter.Value), ","), nil
+	if parameter.Type == types.ParameterTypeStringList {
+		return strings.Split(aws.ToString(parameter.Value), ",")
 	}
 
-	return StringOrMap(aws.ToString(result.Parameter.Value)), nil
+	return StringOrMap(aws.ToString(parameter.Value))
+}
+
+// ssmParametersAPI is the part of the SSM client fetchSSMParameters uses.
+type ssmParametersAPI interface {
+	GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
+}
+
+// fetchSSMParameters fetches parameters of one region with GetParameters, 10 names per call. Names may
+// carry a :version or :label selector. Parameters that are not found are missing from the result, and
+// the values fetched before a call fails are returned with its error.
+func fetchSSMParameters(ctx context.Context, svc ssmParametersAPI, names []string) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(names))
+	for start := 0; start < len(names); start += 10 {
+		out, err := svc.GetParameters(ctx, &ssm.GetParametersInput{
+			Names:          names[start:min(start+10, len(names))],
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return values, fmt.Errorf("unable to retrieve parameters, %v", err)
+		}
+		for _, parameter := range out.Parameters {
+			values[aws.ToString(parameter.Name)+aws.ToString(parameter.Selector)] = parameterValue(parameter)
+		}
+	}
+	return values, nil
 }
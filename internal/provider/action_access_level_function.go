@@ -0,0 +1,58 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var (
+	_ function.Function = &ActionAccessLevel{}
+)
+
+func ActionAccessLevelFunction() function.Function {
+	return &ActionAccessLevel{}
+}
+
+type ActionAccessLevel struct{}
+
+func (r ActionAccessLevel) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "action_access_level"
+}
+
+func (f *ActionAccessLevel) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Return the access level of an IAM action",
+		Description: "Given an action, will return its access level from the action catalog bundled with the provider: List, Read, Write, Permissions management or Tagging. Action names are matched case insensitively.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "action",
+				Description: "The action, for example s3:PutBucketPolicy",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *ActionAccessLevel) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var action string
+
+	resp.Error = req.Arguments.Get(ctx, &action)
+	if resp.Error != nil {
+		return
+	}
+
+	_, info, ok := awscloud.LookupAction(action)
+	if !ok {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Unknown action %q, it is not in the bundled action catalog", action))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, info.AccessLevel)
+}
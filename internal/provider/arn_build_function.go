@@ -0,0 +1,101 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = &ArnBuild{}
+)
+
+func ArnBuildFunction() function.Function {
+	return &ArnBuild{}
+}
+
+type ArnBuild struct{}
+
+func (r ArnBuild) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "arn_build"
+}
+
+func (f *ArnBuild) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Build and validate an ARN from its fields",
+		Description: "Given an object with service, region, account, resource_type and resource_id, and optionally partition (defaults to aws), will return the ARN. The fields are validated, and for known services so are the region, the account and the resource type, e.g. iam ARNs have no region and kms resources are a key or an alias.",
+
+		Parameters: []function.Parameter{
+			function.DynamicParameter{
+				Name:        "parts",
+				Description: "An object with the ARN fields, as returned by arn_parse. Omitted or null fields are empty",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *ArnBuild) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var parts types.Dynamic
+
+	resp.Error = req.Arguments.Get(ctx, &parts)
+	if resp.Error != nil {
+		return
+	}
+
+	value, err := dynamicToGoType(parts)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error reading parts: %s", err.Error()))
+		return
+	}
+
+	fields, ok := value.(map[string]any)
+	if !ok {
+		resp.Error = function.NewArgumentFuncError(0, "Error reading parts: parts must be an object")
+		return
+	}
+
+	built := awscloud.ARN{Partition: "aws"}
+	for k, v := range fields {
+		if v == nil {
+			continue
+		}
+
+		s, ok := v.(string)
+		if !ok {
+			resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error reading parts: %s must be a string", k))
+			return
+		}
+
+		switch k {
+		case "partition":
+			built.Partition = s
+		case "service":
+			built.Service = s
+		case "region":
+			built.Region = s
+		case "account":
+			built.Account = s
+		case "resource_type":
+			built.ResourceType = s
+		case "resource_id":
+			built.ResourceID = s
+		default:
+			resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error reading parts: unsupported attribute %q", k))
+			return
+		}
+	}
+
+	if err := built.Validate(); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid ARN: %s", err.Error()))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, built.String())
+}
@@ -0,0 +1,55 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var (
+	_ function.Function = &ArnMatch{}
+)
+
+func ArnMatchFunction() function.Function {
+	return &ArnMatch{}
+}
+
+type ArnMatch struct{}
+
+func (r ArnMatch) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "arn_match"
+}
+
+func (f *ArnMatch) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Return whether an ARN matches an IAM style pattern",
+		Description: "Given a pattern and an ARN, will return true when they match the way the ArnLike condition operator does: * matches any run of characters and ? a single one, and each of the six colon separated fields is matched on its own.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "pattern",
+				Description: "The pattern, for example arn:aws:iam::*:role/deploy-*",
+			},
+			function.StringParameter{
+				Name:        "arn",
+				Description: "The ARN to match",
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (f *ArnMatch) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var pattern, arn string
+
+	resp.Error = req.Arguments.Get(ctx, &pattern, &arn)
+	if resp.Error != nil {
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, awscloud.MatchARN(pattern, arn))
+}
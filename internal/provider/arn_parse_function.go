@@ -0,0 +1,79 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = &ArnParse{}
+)
+
+// arnAttrTypes are the fields returned by arn_parse and accepted by arn_build.
+var arnAttrTypes = map[string]attr.Type{
+	"partition":     types.StringType,
+	"service":       types.StringType,
+	"region":        types.StringType,
+	"account":       types.StringType,
+	"resource_type": types.StringType,
+	"resource_id":   types.StringType,
+}
+
+func ArnParseFunction() function.Function {
+	return &ArnParse{}
+}
+
+type ArnParse struct{}
+
+func (r ArnParse) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "arn_parse"
+}
+
+func (f *ArnParse) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Split an ARN into its fields",
+		Description: "Given an ARN, will return an object with partition, service, region, account, resource_type and resource_id. The resource is split at its first / or :, except for s3, sns and sqs whose resources have no type and are returned whole as resource_id.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "arn",
+				Description: "The ARN to parse",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: arnAttrTypes,
+		},
+	}
+}
+
+func (f *ArnParse) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var value string
+
+	resp.Error = req.Arguments.Get(ctx, &value)
+	if resp.Error != nil {
+		return
+	}
+
+	parsed, err := awscloud.ParseARN(value)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error parsing ARN: %s", err.Error()))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, types.ObjectValueMust(arnAttrTypes, map[string]attr.Value{
+		"partition":     types.StringValue(parsed.Partition),
+		"service":       types.StringValue(parsed.Service),
+		"region":        types.StringValue(parsed.Region),
+		"account":       types.StringValue(parsed.Account),
+		"resource_type": types.StringValue(parsed.ResourceType),
+		"resource_id":   types.StringValue(parsed.ResourceID),
+	}))
+}
@@ -0,0 +1,426 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	"github.com/hashicorp/terraform-plugin-framework-validators/datasourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource                     = &cloudFrontDistributionDataSource{}
+	_ datasource.DataSourceWithConfigure        = &cloudFrontDistributionDataSource{}
+	_ datasource.DataSourceWithConfigValidators = &cloudFrontDistributionDataSource{}
+)
+
+// NewCloudFrontDistributionDataSource is a helper function to simplify the provider implementation.
+func NewCloudFrontDistributionDataSource() datasource.DataSource {
+	return &cloudFrontDistributionDataSource{}
+}
+
+// cloudFrontDistributionDataSource is the data source implementation.
+type cloudFrontDistributionDataSource struct {
+	cfg aws.Config
+}
+
+// cloudFrontDistributionDataSourceModel describes the data source data model.
+type cloudFrontDistributionDataSourceModel struct {
+	ID                   types.String `tfsdk:"id"`
+	Alias                types.String `tfsdk:"alias"`
+	Tags                 types.Map    `tfsdk:"tags"`
+	ARN                  types.String `tfsdk:"arn"`
+	DomainName           types.String `tfsdk:"domain_name"`
+	Status               types.String `tfsdk:"status"`
+	Enabled              types.Bool   `tfsdk:"enabled"`
+	Aliases              types.List   `tfsdk:"aliases"`
+	Origins              types.List   `tfsdk:"origins"`
+	DefaultCacheBehavior types.Object `tfsdk:"default_cache_behavior"`
+	FunctionAssociations types.List   `tfsdk:"function_associations"`
+}
+
+type cloudFrontOriginModel struct {
+	ID                    types.String `tfsdk:"id"`
+	DomainName            types.String `tfsdk:"domain_name"`
+	OriginPath            types.String `tfsdk:"origin_path"`
+	OriginAccessControlID types.String `tfsdk:"origin_access_control_id"`
+}
+
+type cloudFrontCacheBehaviorModel struct {
+	TargetOriginID          types.String `tfsdk:"target_origin_id"`
+	ViewerProtocolPolicy    types.String `tfsdk:"viewer_protocol_policy"`
+	CachePolicyID           types.String `tfsdk:"cache_policy_id"`
+	OriginRequestPolicyID   types.String `tfsdk:"origin_request_policy_id"`
+	ResponseHeadersPolicyID types.String `tfsdk:"response_headers_policy_id"`
+	AllowedMethods          types.List   `tfsdk:"allowed_methods"`
+	CachedMethods           types.List   `tfsdk:"cached_methods"`
+	Compress                types.Bool   `tfsdk:"compress"`
+}
+
+type cloudFrontFunctionAssociationModel struct {
+	PathPattern types.String `tfsdk:"path_pattern"`
+	EventType   types.String `tfsdk:"event_type"`
+	FunctionARN types.String `tfsdk:"function_arn"`
+	Type        types.String `tfsdk:"type"`
+}
+
+var cloudFrontOriginAttrTypes = map[string]attr.Type{
+	"id":                       types.StringType,
+	"domain_name":              types.StringType,
+	"origin_path":              types.StringType,
+	"origin_access_control_id": types.StringType,
+}
+
+var cloudFrontCacheBehaviorAttrTypes = map[string]attr.Type{
+	"target_origin_id":           types.StringType,
+	"viewer_protocol_policy":     types.StringType,
+	"cache_policy_id":            types.StringType,
+	"origin_request_policy_id":   types.StringType,
+	"response_headers_policy_id": types.StringType,
+	"allowed_methods":            types.ListType{ElemType: types.StringType},
+	"cached_methods":             types.ListType{ElemType: types.StringType},
+	"compress":                   types.BoolType,
+}
+
+var cloudFrontFunctionAssociationAttrTypes = map[string]attr.Type{
+	"path_pattern": types.StringType,
+	"event_type":   types.StringType,
+	"function_arn": types.StringType,
+	"type":         types.StringType,
+}
+
+// Metadata returns the data source type name.
+func (d *cloudFrontDistributionDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_cloudfront_distribution"
+}
+
+// Schema defines the schema for the data source.
+func (d *cloudFrontDistributionDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Finds a CloudFront distribution by ID, alternate domain name (CNAME) or tags.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "The ID of the distribution. Takes precedence over `alias` and `tags`.",
+			},
+			"alias": schema.StringAttribute{
+				Optional:    true,
+				Description: "An alternate domain name (CNAME) served by the distribution.",
+			},
+			"tags": schema.MapAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Tags the distribution must carry. Combined with `alias`, the distribution must match both. Exactly one distribution must match.",
+			},
+			"arn": schema.StringAttribute{
+				Computed:    true,
+				Description: "The ARN of the distribution.",
+			},
+			"domain_name": schema.StringAttribute{
+				Computed:    true,
+				Description: "The CloudFront domain name of the distribution, for example d111111abcdef8.cloudfront.net.",
+			},
+			"status": schema.StringAttribute{
+				Computed:    true,
+				Description: "The status of the distribution, `Deployed` or `InProgress`.",
+			},
+			"enabled": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether the distribution is enabled.",
+			},
+			"aliases": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "The alternate domain names (CNAMEs) of the distribution.",
+			},
+			"origins": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "The origins of the distribution.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							Computed:    true,
+							Description: "The unique identifier of the origin.",
+						},
+						"domain_name": schema.StringAttribute{
+							Computed:    true,
+							Description: "The domain name of the origin.",
+						},
+						"origin_path": schema.StringAttribute{
+							Computed:    true,
+							Description: "The path CloudFront appends to requests sent to the origin.",
+						},
+						"origin_access_control_id": schema.StringAttribute{
+							Computed:    true,
+							Description: "The origin access control attached to the origin.",
+						},
+					},
+				},
+			},
+			"default_cache_behavior": schema.SingleNestedAttribute{
+				Computed:    true,
+				Description: "The default cache behavior of the distribution.",
+				Attributes: map[string]schema.Attribute{
+					"target_origin_id": schema.StringAttribute{
+						Computed:    true,
+						Description: "The origin requests are routed to.",
+					},
+					"viewer_protocol_policy": schema.StringAttribute{
+						Computed:    true,
+						Description: "The protocol viewers can use to access the content.",
+					},
+					"cache_policy_id": schema.StringAttribute{
+						Computed:    true,
+						Description: "The cache policy attached to the behavior.",
+					},
+					"origin_request_policy_id": schema.StringAttribute{
+						Computed:    true,
+						Description: "The origin request policy attached to the behavior.",
+					},
+					"response_headers_policy_id": schema.StringAttribute{
+						Computed:    true,
+						Description: "The response headers policy attached to the behavior.",
+					},
+					"allowed_methods": schema.ListAttribute{
+						Computed:    true,
+						ElementType: types.StringType,
+						Description: "The HTTP methods CloudFront processes and forwards to the origin.",
+					},
+					"cached_methods": schema.ListAttribute{
+						Computed:    true,
+						ElementType: types.StringType,
+						Description: "The HTTP methods whose responses CloudFront caches.",
+					},
+					"compress": schema.BoolAttribute{
+						Computed:    true,
+						Description: "Whether CloudFront compresses the content.",
+					},
+				},
+			},
+			"function_associations": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "The CloudFront Functions and Lambda@Edge functions attached to the default and ordered cache behaviors.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"path_pattern": schema.StringAttribute{
+							Computed:    true,
+							Description: "The path pattern of the cache behavior, `*` for the default cache behavior.",
+						},
+						"event_type": schema.StringAttribute{
+							Computed:    true,
+							Description: "The event that triggers the function, for example `viewer-request`.",
+						},
+						"function_arn": schema.StringAttribute{
+							Computed:    true,
+							Description: "The ARN of the function.",
+						},
+						"type": schema.StringAttribute{
+							Computed:    true,
+							Description: "`cloudfront` for CloudFront Functions, `lambda` for Lambda@Edge.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *cloudFrontDistributionDataSource) ConfigValidators(_ context.Context) []datasource.ConfigValidator {
+	return []datasource.ConfigValidator{
+		datasourcevalidator.AtLeastOneOf(
+			path.MatchRoot("id"),
+			path.MatchRoot("alias"),
+			path.MatchRoot("tags"),
+		),
+	}
+}
+
+func (d *cloudFrontDistributionDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	cfg, ok := req.ProviderData.(aws.Config)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *aws.Config, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.cfg = cfg
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *cloudFrontDistributionDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state cloudFrontDistributionDataSourceModel
+
+	// Get configuration
+	resp.Diagnostics.Append(req.Config.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	filter := awscloud.DistributionFilter{}
+	if state.ID.ValueString() != "" {
+		filter.IDs = []string{state.ID.ValueString()}
+	}
+	if state.Alias.ValueString() != "" {
+		filter.Aliases = []string{state.Alias.ValueString()}
+	}
+	resp.Diagnostics.Append(state.Tags.ElementsAs(ctx, &filter.Tags, true)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dist, err := awscloud.LookupDistribution(ctx, d.cfg, filter)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error retrieving CloudFront distribution",
+			"Unable to find the CloudFront distribution: "+err.Error(),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(state.fromDistribution(ctx, dist)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Set the new state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// fromDistribution copies the distribution details into the model.
+func (m *cloudFrontDistributionDataSourceModel) fromDistribution(ctx context.Context, dist *cftypes.Distribution) (diags diag.Diagnostics) {
+	config := dist.DistributionConfig
+	if config == nil {
+		config = &cftypes.DistributionConfig{}
+	}
+
+	m.ID = types.StringPointerValue(dist.Id)
+	m.ARN = types.StringPointerValue(dist.ARN)
+	m.DomainName = types.StringPointerValue(dist.DomainName)
+	m.Status = types.StringPointerValue(dist.Status)
+	m.Enabled = types.BoolPointerValue(config.Enabled)
+
+	aliases := []string{}
+	if config.Aliases != nil && config.Aliases.Items != nil {
+		aliases = config.Aliases.Items
+	}
+	m.Aliases, diags = types.ListValueFrom(ctx, types.StringType, aliases)
+	if diags.HasError() {
+		return diags
+	}
+
+	origins := []cloudFrontOriginModel{}
+	if config.Origins != nil {
+		for _, origin := range config.Origins.Items {
+			origins = append(origins, cloudFrontOriginModel{
+				ID:                    types.StringPointerValue(origin.Id),
+				DomainName:            types.StringPointerValue(origin.DomainName),
+				OriginPath:            types.StringPointerValue(origin.OriginPath),
+				OriginAccessControlID: types.StringPointerValue(origin.OriginAccessControlId),
+			})
+		}
+	}
+	m.Origins, diags = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: cloudFrontOriginAttrTypes}, origins)
+	if diags.HasError() {
+		return diags
+	}
+
+	functions := []cloudFrontFunctionAssociationModel{}
+	m.DefaultCacheBehavior = types.ObjectNull(cloudFrontCacheBehaviorAttrTypes)
+
+	if behavior := config.DefaultCacheBehavior; behavior != nil {
+		allowedMethods, cachedMethods := []string{}, []string{}
+		if behavior.AllowedMethods != nil {
+			for _, method := range behavior.AllowedMethods.Items {
+				allowedMethods = append(allowedMethods, string(method))
+			}
+			if behavior.AllowedMethods.CachedMethods != nil {
+				for _, method := range behavior.AllowedMethods.CachedMethods.Items {
+					cachedMethods = append(cachedMethods, string(method))
+				}
+			}
+		}
+
+		model := cloudFrontCacheBehaviorModel{
+			TargetOriginID:          types.StringPointerValue(behavior.TargetOriginId),
+			ViewerProtocolPolicy:    types.StringValue(string(behavior.ViewerProtocolPolicy)),
+			CachePolicyID:           types.StringPointerValue(behavior.CachePolicyId),
+			OriginRequestPolicyID:   types.StringPointerValue(behavior.OriginRequestPolicyId),
+			ResponseHeadersPolicyID: types.StringPointerValue(behavior.ResponseHeadersPolicyId),
+			Compress:                types.BoolPointerValue(behavior.Compress),
+		}
+
+		model.AllowedMethods, diags = types.ListValueFrom(ctx, types.StringType, allowedMethods)
+		if diags.HasError() {
+			return diags
+		}
+		model.CachedMethods, diags = types.ListValueFrom(ctx, types.StringType, cachedMethods)
+		if diags.HasError() {
+			return diags
+		}
+
+		m.DefaultCacheBehavior, diags = types.ObjectValueFrom(ctx, cloudFrontCacheBehaviorAttrTypes, model)
+		if diags.HasError() {
+			return diags
+		}
+
+		functions = appendFunctionAssociations(functions, "*", behavior.FunctionAssociations, behavior.LambdaFunctionAssociations)
+	}
+
+	if config.CacheBehaviors != nil {
+		for _, behavior := range config.CacheBehaviors.Items {
+			functions = appendFunctionAssociations(functions, aws.ToString(behavior.PathPattern), behavior.FunctionAssociations, behavior.LambdaFunctionAssociations)
+		}
+	}
+
+	m.FunctionAssociations, diags = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: cloudFrontFunctionAssociationAttrTypes}, functions)
+
+	return diags
+}
+
+func appendFunctionAssociations(functions []cloudFrontFunctionAssociationModel, pathPattern string, cfFunctions *cftypes.FunctionAssociations, lambdaFunctions *cftypes.LambdaFunctionAssociations) []cloudFrontFunctionAssociationModel {
+	if cfFunctions != nil {
+		for _, fn := range cfFunctions.Items {
+			functions = append(functions, cloudFrontFunctionAssociationModel{
+				PathPattern: types.StringValue(pathPattern),
+				EventType:   types.StringValue(string(fn.EventType)),
+				FunctionARN: types.StringPointerValue(fn.FunctionARN),
+				Type:        types.StringValue("cloudfront"),
+			})
+		}
+	}
+
+	if lambdaFunctions != nil {
+		for _, fn := range lambdaFunctions.Items {
+			functions = append(functions, cloudFrontFunctionAssociationModel{
+				PathPattern: types.StringValue(pathPattern),
+				EventType:   types.StringValue(string(fn.EventType)),
+				FunctionARN: types.StringPointerValue(fn.LambdaFunctionARN),
+				Type:        types.StringValue("lambda"),
+			})
+		}
+	}
+
+	return functions
+}
@@ -0,0 +1,336 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	kvstypes "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore/types"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &CfKvsSyncResource{}
+var _ resource.ResourceWithImportState = &CfKvsSyncResource{}
+var _ resource.ResourceWithConfigValidators = &CfKvsSyncResource{}
+var _ resource.ResourceWithModifyPlan = &CfKvsSyncResource{}
+
+func NewCfKvsSyncResource() resource.Resource {
+	return &CfKvsSyncResource{}
+}
+
+// CfKvsSyncResource defines the resource implementation.
+type CfKvsSyncResource struct {
+	cfg aws.Config
+}
+
+// CfKvsSyncResourceModel describes the resource data model.
+type CfKvsSyncResourceModel struct {
+	KvsArn   types.String `tfsdk:"kvs_arn"`
+	Items    types.Map    `tfsdk:"items"`
+	JsonFile types.String `tfsdk:"json_file"`
+	Prune    types.Bool   `tfsdk:"prune"`
+	ETag     types.String `tfsdk:"etag"`
+}
+
+func (r *CfKvsSyncResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_" + "cloudfront_kvs_sync"
+}
+
+func (r *CfKvsSyncResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Keeps the keys of a CloudFront KeyValueStore in sync with a map or a JSON file. Changes are applied with `UpdateKeys` batches guarded by the store ETag.",
+		Attributes: map[string]schema.Attribute{
+			"kvs_arn": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "ARN of the CloudFront KeyValueStore",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"items": schema.MapAttribute{
+				Optional:            true,
+				Computed:            true,
+				ElementType:         types.StringType,
+				MarkdownDescription: "Keys and values the store should hold. Conflicts with `json_file`, which fills it when set. Keys changed outside Terraform show up as drift",
+			},
+			"json_file": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Path to a JSON file holding an object of keys to values. Non string values are stored JSON encoded",
+			},
+			"prune": schema.BoolAttribute{
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+				MarkdownDescription: "Delete keys in the store that are not in `items`. When false, only keys previously managed by this resource are deleted. Defaults to `true`",
+			},
+			"etag": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "ETag of the store after the last sync",
+			},
+		},
+	}
+
+}
+
+func (r *CfKvsSyncResource) ConfigValidators(ctx context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.ExactlyOneOf(
+			path.MatchRoot("items"),
+			path.MatchRoot("json_file"),
+		),
+	}
+}
+
+func (r *CfKvsSyncResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// provider configuration
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	cfg, ok := req.ProviderData.(aws.Config)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *aws.Config, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.cfg = cfg
+
+}
+
+// ModifyPlan loads json_file into items, so changes to the file show up in the plan.
+func (r *CfKvsSyncResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// nothing to do on destroy
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan CfKvsSyncResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() || plan.JsonFile.IsNull() || plan.JsonFile.IsUnknown() {
+		return
+	}
+
+	items, err := kvsItemsFromFile(plan.JsonFile.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("json_file"), "Error reading JSON file", err.Error())
+		return
+	}
+
+	itemsValue, diags := types.MapValueFrom(ctx, types.StringType, items)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("items"), itemsValue)...)
+}
+
+// kvsItemsFromFile reads a JSON object of keys to values, encoding non string values as JSON.
+func kvsItemsFromFile(filePath string) (map[string]string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("%s is not a JSON object: %w", filePath, err)
+	}
+
+	items := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			items[k] = s
+			continue
+		}
+
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode value of %s: %w", k, err)
+		}
+		items[k] = string(encoded)
+	}
+
+	return items, nil
+}
+
+func (r *CfKvsSyncResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan CfKvsSyncResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.sync(ctx, &plan, nil)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *CfKvsSyncResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state CfKvsSyncResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	current, err := awscloud.ListKVSKeys(ctx, r.cfg, state.KvsArn.ValueString())
+
+	var notFound *kvstypes.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		tflog.Warn(ctx, fmt.Sprintf("KeyValueStore %s not found, removing from state", state.KvsArn.ValueString()))
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	if err != nil {
+		resp.Diagnostics.AddError("Error", fmt.Sprint("Unable to read KeyValueStore...", err))
+		return
+	}
+
+	// imported resources have no prune value yet.
+	if state.Prune.IsNull() {
+		state.Prune = types.BoolValue(true)
+	}
+
+	// with prune every key of the store is managed, otherwise only the keys already in state.
+	items := current
+	if !state.Prune.ValueBool() {
+		managed := make(map[string]string)
+		resp.Diagnostics.Append(state.Items.ElementsAs(ctx, &managed, true)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		items = make(map[string]string, len(managed))
+		for k := range managed {
+			if v, ok := current[k]; ok {
+				items[k] = v
+			}
+		}
+	}
+
+	itemsValue, diags := types.MapValueFrom(ctx, types.StringType, items)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	state.Items = itemsValue
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *CfKvsSyncResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state CfKvsSyncResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.sync(ctx, &plan, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *CfKvsSyncResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state CfKvsSyncResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// only remove the keys this resource manages.
+	managed := make(map[string]string)
+	resp.Diagnostics.Append(state.Items.ElementsAs(ctx, &managed, true)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	keys := make([]string, 0, len(managed))
+	for k := range managed {
+		keys = append(keys, k)
+	}
+
+	err := awscloud.DeleteKVSKeys(ctx, r.cfg, state.KvsArn.ValueString(), keys)
+
+	var notFound *kvstypes.ResourceNotFoundException
+	if err != nil && !errors.As(err, &notFound) {
+		resp.Diagnostics.AddError("Error", fmt.Sprint("Unable to delete keys...", err))
+	}
+}
+
+func (r *CfKvsSyncResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("kvs_arn"), req, resp)
+}
+
+// sync applies the planned items to the store and records the resulting ETag.
+func (r *CfKvsSyncResource) sync(ctx context.Context, plan *CfKvsSyncResourceModel, state *CfKvsSyncResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	desired := make(map[string]string)
+	diags.Append(plan.Items.ElementsAs(ctx, &desired, true)...)
+
+	managed := make(map[string]string)
+	if state != nil {
+		diags.Append(state.Items.ElementsAs(ctx, &managed, true)...)
+	}
+	if diags.HasError() {
+		return diags
+	}
+
+	managedKeys := make([]string, 0, len(managed))
+	for k := range managed {
+		managedKeys = append(managedKeys, k)
+	}
+
+	etag, err := awscloud.SyncKVS(ctx, r.cfg, plan.KvsArn.ValueString(), desired, managedKeys, plan.Prune.ValueBool())
+	if err != nil {
+		diags.AddError("Error", fmt.Sprint("Unable to sync KeyValueStore...", err))
+		return diags
+	}
+
+	itemsValue, d := types.MapValueFrom(ctx, types.StringType, desired)
+	diags.Append(d...)
+	plan.Items = itemsValue
+	plan.ETag = types.StringValue(etag)
+
+	return diags
+}
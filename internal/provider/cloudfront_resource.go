@@ -5,15 +5,22 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	awscloud "terraform-provider-awsutils/internal/aws_cloud"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -23,6 +30,7 @@ import (
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &CfResource{}
 var _ resource.ResourceWithImportState = &CfResource{}
+var _ resource.ResourceWithConfigValidators = &CfResource{}
 
 func NewCfResource() resource.Resource {
 	return &CfResource{}
@@ -39,7 +47,26 @@ type CfResourceModel struct {
 	Paths           types.List   `tfsdk:"paths"`
 	InValidation_Id types.String `tfsdk:"invalidation_id"`
 	// Status        types.String `tfsdk:"status"`
-	Trigger types.String `tfsdk:"trigger"`
+	Trigger           types.String `tfsdk:"trigger"`
+	WildcardThreshold types.Int64  `tfsdk:"wildcard_threshold"`
+	InvalidationIds   types.List   `tfsdk:"invalidation_ids"`
+	DistributionIds   types.List   `tfsdk:"distribution_ids"`
+	Aliases           types.List   `tfsdk:"aliases"`
+	Tags              types.Map    `tfsdk:"tags"`
+	Invalidations     types.Map    `tfsdk:"invalidations"`
+}
+
+// CfInvalidationModel describes the invalidations created on one distribution.
+type CfInvalidationModel struct {
+	InvalidationId  types.String `tfsdk:"invalidation_id"`
+	InvalidationIds types.List   `tfsdk:"invalidation_ids"`
+	Status          types.String `tfsdk:"status"`
+}
+
+var cfInvalidationAttrTypes = map[string]attr.Type{
+	"invalidation_id":  types.StringType,
+	"invalidation_ids": types.ListType{ElemType: types.StringType},
+	"status":           types.StringType,
 }
 
 func (r *CfResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -53,11 +80,35 @@ func (r *CfResource) Schema(ctx context.Context, req resource.SchemaRequest, res
 		Attributes: map[string]schema.Attribute{
 
 			"distribution_id": schema.StringAttribute{
-				Required:            true,
+				Optional:            true,
 				MarkdownDescription: "Cloudfront distribution ID",
-				// PlanModifiers: []planmodifier.String{
-				// 	stringplanmodifier.RequiresReplaceIfConfigured(),
-				// },
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"distribution_ids": schema.ListAttribute{
+				Optional:            true,
+				MarkdownDescription: "Cloudfront distribution IDs. All distributions are invalidated concurrently",
+				ElementType:         types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"aliases": schema.ListAttribute{
+				Optional:            true,
+				MarkdownDescription: "Invalidate the distributions serving any of these alternate domain names (CNAMEs)",
+				ElementType:         types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"tags": schema.MapAttribute{
+				Optional:            true,
+				MarkdownDescription: "Invalidate the distributions carrying all of these tags. Combined with `aliases`, a distribution must match both",
+				ElementType:         types.StringType,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplaceIfConfigured(),
+				},
 			},
 			"paths": schema.ListAttribute{
 				MarkdownDescription: "Cache invalidation paths - defaults to `/*`",
@@ -76,14 +127,55 @@ func (r *CfResource) Schema(ctx context.Context, req resource.SchemaRequest, res
 				// 	listplanmodifier.RequiresReplaceIfConfigured(),
 				// },
 			},
+			"wildcard_threshold": schema.Int64Attribute{
+				Optional:            true,
+				Computed:            true,
+				MarkdownDescription: "Collapse sibling paths into a `dir/*` wildcard when a directory has more than this many paths. Defaults to `0`, which disables collapsing",
+				Default:             int64default.StaticInt64(0),
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
 			"invalidation_id": schema.StringAttribute{
 				Computed:            true,
-				MarkdownDescription: "Cloudfront cache invalidation ID",
+				MarkdownDescription: "Cloudfront cache invalidation ID of the first batch on `distribution_id`, or on the first distribution when it is not set",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
 					stringplanmodifier.RequiresReplaceIfConfigured(),
 				},
 			},
+			"invalidation_ids": schema.ListAttribute{
+				Computed:            true,
+				MarkdownDescription: "Cloudfront cache invalidation IDs of the same distribution as `invalidation_id`, one per batch. Paths are split into batches of at most 3000 paths and 15 wildcards",
+				ElementType:         types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"invalidations": schema.MapNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "Invalidations keyed by distribution ID",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"invalidation_id": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Cloudfront cache invalidation ID of the first batch",
+						},
+						"invalidation_ids": schema.ListAttribute{
+							Computed:            true,
+							MarkdownDescription: "Cloudfront cache invalidation IDs, one per batch",
+							ElementType:         types.StringType,
+						},
+						"status": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "`Completed` once every batch is completed, `InProgress` otherwise",
+						},
+					},
+				},
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.UseStateForUnknown(),
+				},
+			},
 			// "status": schema.StringAttribute{
 			// 	Computed:            true,
 			// 	MarkdownDescription: "Cloudfront cache invalidation status",
@@ -106,6 +198,17 @@ func (r *CfResource) Schema(ctx context.Context, req resource.SchemaRequest, res
 
 }
 
+func (r *CfResource) ConfigValidators(ctx context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.AtLeastOneOf(
+			path.MatchRoot("distribution_id"),
+			path.MatchRoot("distribution_ids"),
+			path.MatchRoot("aliases"),
+			path.MatchRoot("tags"),
+		),
+	}
+}
+
 func (r *CfResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	// provider configuration
 	// Prevent panic if the provider has not been configured.
@@ -161,8 +264,36 @@ func (r *CfResource) Create(ctx context.Context, req resource.CreateRequest, res
 
 	// resp.Diagnostics.AddError("info", fmt.Sprintf("Invalidating cache info...%T", paths))
 
+	filter := awscloud.DistributionFilter{}
+	if !data.Distribution_Id.IsNull() && data.Distribution_Id.ValueString() != "" {
+		filter.IDs = append(filter.IDs, data.Distribution_Id.ValueString())
+	}
+
+	var distributionIds, aliases []string
+	resp.Diagnostics.Append(data.DistributionIds.ElementsAs(ctx, &distributionIds, true)...)
+	resp.Diagnostics.Append(data.Aliases.ElementsAs(ctx, &aliases, true)...)
+	resp.Diagnostics.Append(data.Tags.ElementsAs(ctx, &filter.Tags, true)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	filter.IDs = append(filter.IDs, distributionIds...)
+	filter.Aliases = aliases
+
+	ids, err := awscloud.ResolveDistributionIDs(ctx, r.cfg, filter)
+	if err != nil {
+		resp.Diagnostics.AddError("Error", fmt.Sprint("Unable to find distributions...", err.Error()))
+		return
+	}
+
+	if len(ids) == 0 {
+		resp.Diagnostics.AddError("Error", "No distribution to invalidate, set distribution_id, distribution_ids, aliases or tags")
+		return
+	}
+
 	// invalidate the cache
-	cacheRes, err := awscloud.InvalidateCache(r.cfg, data.Distribution_Id.ValueString(), paths)
+	cacheRes, err := awscloud.InvalidateDistributions(ctx, r.cfg, ids, paths, awscloud.InvalidationOptions{
+		WildcardThreshold: int(data.WildcardThreshold.ValueInt64()),
+	})
 
 	if err != nil {
 		resp.Diagnostics.AddError("Error", fmt.Sprint("Unable to invalidate cache...", err.Error()))
@@ -170,7 +301,38 @@ func (r *CfResource) Create(ctx context.Context, req resource.CreateRequest, res
 		return
 	}
 
-	data.InValidation_Id = types.StringPointerValue(cacheRes.Invalidation.Id)
+	invalidations := make(map[string]CfInvalidationModel, len(ids))
+	for _, distId := range ids {
+		res := cacheRes[distId]
+		invalidationIds := make([]string, 0, len(res))
+		statuses := make([]string, 0, len(res))
+		for _, inv := range res {
+			invalidationIds = append(invalidationIds, aws.ToString(inv.Invalidation.Id))
+			statuses = append(statuses, aws.ToString(inv.Invalidation.Status))
+		}
+
+		model, diags := newCfInvalidationModel(ctx, invalidationIds, statuses)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		invalidations[distId] = model
+	}
+
+	// the top level attributes describe distribution_id, or the first distribution when it is not set.
+	primary := ids[0]
+	if !data.Distribution_Id.IsNull() && data.Distribution_Id.ValueString() != "" {
+		primary = data.Distribution_Id.ValueString()
+	}
+	data.InValidation_Id = invalidations[primary].InvalidationId
+	data.InvalidationIds = invalidations[primary].InvalidationIds
+
+	invalidationsValue, diags := types.MapValueFrom(ctx, types.ObjectType{AttrTypes: cfInvalidationAttrTypes}, invalidations)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.Invalidations = invalidationsValue
 	// data.Status = types.StringPointerValue(cacheRes.Invalidation.Status)
 
 	// Save data into Terraform state
@@ -187,20 +349,100 @@ func (r *CfResource) Read(ctx context.Context, req resource.ReadRequest, resp *r
 		return
 	}
 
-	res, err := awscloud.GetDistribution(r.cfg, data.Distribution_Id.ValueString())
+	invalidations := make(map[string]CfInvalidationModel)
+	resp.Diagnostics.Append(data.Invalidations.ElementsAs(ctx, &invalidations, true)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	if err != nil {
-		resp.Diagnostics.AddError("Error", fmt.Sprint("Unable to get distribution...", err))
-		tflog.Error(ctx, fmt.Sprint("Unable to get distribution...", err))
+	// refresh the status of the invalidations still in progress, completed ones never change.
+	dropped := false
+	for distId, model := range invalidations {
+		if model.Status.ValueString() == "Completed" {
+			continue
+		}
+
+		var invalidationIds []string
+		resp.Diagnostics.Append(model.InvalidationIds.ElementsAs(ctx, &invalidationIds, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		statuses := make([]string, 0, len(invalidationIds))
+		found := true
+		for _, id := range invalidationIds {
+			res, err := awscloud.GetInvalidation(ctx, r.cfg, distId, id)
+			if errors.Is(err, awscloud.ErrInvalidationNotFound) {
+				// the distribution was deleted, so there is nothing left to track on it.
+				tflog.Warn(ctx, fmt.Sprintf("invalidation %s of distribution %s not found, removing the distribution from state", id, distId))
+				found = false
+				break
+			}
+			if err != nil {
+				resp.Diagnostics.AddError("Error", fmt.Sprint("Unable to get invalidation...", err))
+				tflog.Error(ctx, fmt.Sprint("Unable to get invalidation...", err))
+				return
+			}
+			statuses = append(statuses, aws.ToString(res.Invalidation.Status))
+		}
+		if !found {
+			delete(invalidations, distId)
+			dropped = true
+			continue
+		}
+
+		refreshed, diags := newCfInvalidationModel(ctx, invalidationIds, statuses)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		invalidations[distId] = refreshed
+	}
+
+	// the resource is gone only once none of its distributions remain.
+	if dropped && len(invalidations) == 0 {
+		resp.State.RemoveResource(ctx)
 		return
 	}
-	tflog.Info(ctx, fmt.Sprintf("cf distribution: %v ", res))
+	tflog.Info(ctx, fmt.Sprintf("cf invalidations: %v ", invalidations))
+
+	if !data.Invalidations.IsNull() {
+		invalidationsValue, diags := types.MapValueFrom(ctx, types.ObjectType{AttrTypes: cfInvalidationAttrTypes}, invalidations)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		data.Invalidations = invalidationsValue
+	}
 	// data.Status = types.StringPointerValue(res.Distribution.Status)
 
 	// // Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// newCfInvalidationModel builds the state of one distribution, which is only Completed once every batch is.
+func newCfInvalidationModel(ctx context.Context, invalidationIds []string, statuses []string) (CfInvalidationModel, diag.Diagnostics) {
+	model := CfInvalidationModel{
+		InvalidationId: types.StringNull(),
+		Status:         types.StringValue("Completed"),
+	}
+
+	if len(invalidationIds) > 0 {
+		model.InvalidationId = types.StringValue(invalidationIds[0])
+	}
+
+	for _, status := range statuses {
+		if status != "Completed" {
+			model.Status = types.StringValue("InProgress")
+		}
+	}
+
+	var diags diag.Diagnostics
+	model.InvalidationIds, diags = types.ListValueFrom(ctx, types.StringType, invalidationIds)
+
+	return model, diags
+}
+
 func (r *CfResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	// var data CfResourceModel
 
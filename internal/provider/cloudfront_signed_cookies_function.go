@@ -0,0 +1,55 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = &CloudFrontSignedCookies{}
+)
+
+func CloudFrontSignedCookiesFunction() function.Function {
+	return &CloudFrontSignedCookies{}
+}
+
+type CloudFrontSignedCookies struct{}
+
+func (r CloudFrontSignedCookies) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "cloudfront_signed_cookies"
+}
+
+func (f *CloudFrontSignedCookies) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Return the CloudFront signed cookies for private content",
+		Description: "Given a URL, a key pair ID, a PEM private key and an expiry time, will return a map of the three cookies CloudFront expects: CloudFront-Expires (canned policy) or CloudFront-Policy (custom policy), CloudFront-Signature and CloudFront-Key-Pair-Id.",
+
+		Parameters: signParameters(),
+		Return: function.MapReturn{
+			ElementType: types.StringType,
+		},
+	}
+}
+
+func (f *CloudFrontSignedCookies) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	url, keyPairID, privateKey, opts, funcErr := signArguments(ctx, req)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	cookies, err := awscloud.SignedCookies(url, keyPairID, privateKey, opts)
+	if err != nil {
+		resp.Error = function.NewFuncError(fmt.Sprintf("Error signing cookies: %s", err.Error()))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, cookies)
+}
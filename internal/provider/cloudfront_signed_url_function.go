@@ -0,0 +1,145 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = &CloudFrontSignedURL{}
+)
+
+func CloudFrontSignedURLFunction() function.Function {
+	return &CloudFrontSignedURL{}
+}
+
+type CloudFrontSignedURL struct{}
+
+func (r CloudFrontSignedURL) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "cloudfront_signed_url"
+}
+
+// signParameters are shared by cloudfront_signed_url and cloudfront_signed_cookies.
+func signParameters() []function.Parameter {
+	return []function.Parameter{
+		function.StringParameter{
+			Name:        "url",
+			Description: "The URL of the private content, for example https://d111111abcdef8.cloudfront.net/private/file.pdf",
+		},
+		function.StringParameter{
+			Name:        "key_pair_id",
+			Description: "The ID of the CloudFront public key matching the private key",
+		},
+		function.StringParameter{
+			Name:        "private_key",
+			Description: "The PEM encoded RSA private key, PKCS#1 or PKCS#8",
+		},
+		function.StringParameter{
+			Name:        "expires",
+			Description: "The RFC 3339 time the signature expires at, for example the result of timeadd(timestamp(), \"1h\")",
+		},
+		function.DynamicParameter{
+			Name:           "policy",
+			Description:    "Optional custom policy as an object with `not_before` (RFC 3339 time), `ip_address` (CIDR range) and `resource` (URL with wildcards). If null, a canned policy is used",
+			AllowNullValue: true,
+		},
+	}
+}
+
+func (f *CloudFrontSignedURL) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Return a CloudFront signed URL for private content",
+		Description: "Given a URL, a key pair ID, a PEM private key and an expiry time, will return the URL signed with a canned policy, or with a custom policy when a date window, an IP range or a wildcard resource is given.",
+
+		Parameters: signParameters(),
+		Return:     function.StringReturn{},
+	}
+}
+
+// signArguments reads the arguments shared by the signing functions.
+func signArguments(ctx context.Context, req function.RunRequest) (url string, keyPairID string, privateKey string, opts awscloud.SignOptions, funcErr *function.FuncError) {
+	var expires string
+	var policy types.Dynamic
+
+	funcErr = req.Arguments.Get(ctx, &url, &keyPairID, &privateKey, &expires, &policy)
+	if funcErr != nil {
+		return
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, expires)
+	if err != nil {
+		funcErr = function.NewArgumentFuncError(3, fmt.Sprintf("Error parsing expires: %q is not an RFC 3339 time", expires))
+		return
+	}
+	opts.Expires = expiresAt
+
+	policyVal, err := dynamicToGoType(policy)
+	if err != nil {
+		funcErr = function.NewArgumentFuncError(4, fmt.Sprintf("Error reading policy: %s", err.Error()))
+		return
+	}
+	if policyVal == nil {
+		return
+	}
+
+	policyMap, ok := policyVal.(map[string]any)
+	if !ok {
+		funcErr = function.NewArgumentFuncError(4, "Error reading policy: policy must be an object")
+		return
+	}
+
+	for k, v := range policyMap {
+		if v == nil {
+			continue
+		}
+
+		value, ok := v.(string)
+		if !ok {
+			funcErr = function.NewArgumentFuncError(4, fmt.Sprintf("Error reading policy: %s must be a string", k))
+			return
+		}
+
+		switch k {
+		case "not_before":
+			notBefore, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				funcErr = function.NewArgumentFuncError(4, fmt.Sprintf("Error parsing policy.not_before: %q is not an RFC 3339 time", value))
+				return
+			}
+			opts.NotBefore = &notBefore
+		case "ip_address":
+			opts.IPAddress = value
+		case "resource":
+			opts.Resource = value
+		default:
+			funcErr = function.NewArgumentFuncError(4, fmt.Sprintf("Error reading policy: unsupported attribute %q", k))
+			return
+		}
+	}
+
+	return
+}
+
+func (f *CloudFrontSignedURL) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	url, keyPairID, privateKey, opts, funcErr := signArguments(ctx, req)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	signedURL, err := awscloud.SignedURL(url, keyPairID, privateKey, opts)
+	if err != nil {
+		resp.Error = function.NewFuncError(fmt.Sprintf("Error signing URL: %s", err.Error()))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, signedURL)
+}
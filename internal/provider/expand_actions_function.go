@@ -0,0 +1,85 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = &ExpandActions{}
+)
+
+func ExpandActionsFunction() function.Function {
+	return &ExpandActions{}
+}
+
+type ExpandActions struct{}
+
+func (r ExpandActions) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "expand_actions"
+}
+
+func (f *ExpandActions) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Return the IAM actions a wildcard action grants",
+		Description: "Given an action or a list of actions, will return the sorted list of actions they match in the action catalog bundled with the provider, e.g. s3:Get* gives every s3 action starting with Get. Only wildcards of services the catalog lists completely are expanded, others, `*` and actions of services missing from the catalog are returned as written.",
+
+		Parameters: []function.Parameter{
+			function.DynamicParameter{
+				Name:        "actions",
+				Description: "An action such as s3:Get*, or a list of them",
+			},
+		},
+		Return: function.ListReturn{
+			ElementType: types.StringType,
+		},
+	}
+}
+
+func (f *ExpandActions) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var actionsArg types.Dynamic
+
+	resp.Error = req.Arguments.Get(ctx, &actionsArg)
+	if resp.Error != nil {
+		return
+	}
+
+	value, err := dynamicToGoType(actionsArg)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error reading actions: %s", err.Error()))
+		return
+	}
+
+	var actions []string
+	switch v := value.(type) {
+	case string:
+		actions = []string{v}
+	case []any:
+		for i, e := range v {
+			action, ok := e.(string)
+			if !ok {
+				resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error reading actions: element %d must be a string", i+1))
+				return
+			}
+			actions = append(actions, action)
+		}
+	default:
+		resp.Error = function.NewArgumentFuncError(0, "Error reading actions: actions must be a string or a list of strings")
+		return
+	}
+
+	expanded, err := awscloud.ExpandActions(actions)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error expanding actions: %s", err.Error()))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, stringListValue(expanded))
+}
@@ -0,0 +1,23 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+)
+
+func NewKmsKeyPolicyStatementsResource() resource.Resource {
+	return &PolicyStatementsResource{
+		typeName:          "kms_key_policy_statements",
+		target:            "key_id",
+		targetKind:        "KMS key",
+		description:       "Owns a set of statements, identified by Sid, in a KMS key policy shared with other stacks. Every apply reads the key policy, replaces only the owned statements and retries when another writer changes it concurrently. Destroy removes only the owned statements, and fails rather than leave the key policy without statements.",
+		targetDescription: "ID or ARN of the KMS key",
+		regionDescription: "Region of the key. Defaults to the provider region",
+		policyDescription: "Policy document holding the statements to own. Every statement needs a Sid that no other statement of the key policy uses. Keep a statement granting the account or an administrator `kms:*`, or the key becomes unmanageable",
+		newStore:          awscloud.NewKeyPolicyStore,
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var (
+	_ function.Function = &PolicyAllows{}
+)
+
+func PolicyAllowsFunction() function.Function {
+	return &PolicyAllows{}
+}
+
+type PolicyAllows struct{}
+
+func (r PolicyAllows) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "policy_allows"
+}
+
+func (f *PolicyAllows) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Return whether IAM policies allow a request",
+		Description: "Given policies, a principal, an action, a resource and a request context, will return true when a statement allows the request and none explicitly denies it. Use policy_evaluate for the decision and the matching Sids.",
+
+		Parameters: evalParameters(),
+		Return:     function.BoolReturn{},
+	}
+}
+
+func (f *PolicyAllows) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	result, funcErr := evalArguments(ctx, req)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, result.Decision == awscloud.DecisionAllow)
+}
@@ -0,0 +1,138 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = &PolicyDiff{}
+)
+
+var permissionAttrTypes = map[string]attr.Type{
+	"effect":    types.StringType,
+	"principal": types.StringType,
+	"action":    types.StringType,
+	"resource":  types.StringType,
+	"condition": types.StringType,
+}
+
+var conditionChangeAttrTypes = map[string]attr.Type{
+	"effect":         types.StringType,
+	"principal":      types.StringType,
+	"action":         types.StringType,
+	"resource":       types.StringType,
+	"old_conditions": types.ListType{ElemType: types.StringType},
+	"new_conditions": types.ListType{ElemType: types.StringType},
+}
+
+var policyDiffAttrTypes = map[string]attr.Type{
+	"added":              types.ListType{ElemType: types.ObjectType{AttrTypes: permissionAttrTypes}},
+	"removed":            types.ListType{ElemType: types.ObjectType{AttrTypes: permissionAttrTypes}},
+	"changed_conditions": types.ListType{ElemType: types.ObjectType{AttrTypes: conditionChangeAttrTypes}},
+	"widens_access":      types.BoolType,
+}
+
+func PolicyDiffFunction() function.Function {
+	return &PolicyDiff{}
+}
+
+type PolicyDiff struct{}
+
+func (r PolicyDiff) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "policy_diff"
+}
+
+func (f *PolicyDiff) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Return the permissions a policy change adds or removes",
+		Description: "Given an old and a new IAM policy JSON string, will return an object with the `added` and `removed` permissions and the `changed_conditions`, each broken down per effect, principal, action and resource, and `widens_access`, set when the change may allow something the old policy did not. Values from NotPrincipal, NotAction and NotResource are prefixed with `!`, principals with their type, e.g. `AWS:arn:aws:iam::111122223333:root`. Conditions are given as canonical JSON, empty when unconditional.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "old_policy",
+				Description: "Old policy in string format",
+			},
+			function.StringParameter{
+				Name:        "new_policy",
+				Description: "New policy in string format",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: policyDiffAttrTypes,
+		},
+	}
+}
+
+func (f *PolicyDiff) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var oldJSON, newJSON string
+
+	resp.Error = req.Arguments.Get(ctx, &oldJSON, &newJSON)
+	if resp.Error != nil {
+		return
+	}
+
+	var oldPolicy, newPolicy awscloud.Policy
+	if err := json.Unmarshal([]byte(oldJSON), &oldPolicy); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error unmarshalling old_policy: %s", err.Error()))
+		return
+	}
+	if err := json.Unmarshal([]byte(newJSON), &newPolicy); err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Error unmarshalling new_policy: %s", err.Error()))
+		return
+	}
+
+	diff := awscloud.DiffPolicies(oldPolicy, newPolicy)
+
+	changed := make([]attr.Value, 0, len(diff.ChangedConditions))
+	for _, c := range diff.ChangedConditions {
+		changed = append(changed, types.ObjectValueMust(conditionChangeAttrTypes, map[string]attr.Value{
+			"effect":         types.StringValue(c.Effect),
+			"principal":      types.StringValue(c.Principal),
+			"action":         types.StringValue(c.Action),
+			"resource":       types.StringValue(c.Resource),
+			"old_conditions": stringListValue(c.OldConditions),
+			"new_conditions": stringListValue(c.NewConditions),
+		}))
+	}
+
+	value := types.ObjectValueMust(policyDiffAttrTypes, map[string]attr.Value{
+		"added":              permissionListValue(diff.Added),
+		"removed":            permissionListValue(diff.Removed),
+		"changed_conditions": types.ListValueMust(types.ObjectType{AttrTypes: conditionChangeAttrTypes}, changed),
+		"widens_access":      types.BoolValue(diff.WidensAccess),
+	})
+
+	resp.Error = resp.Result.Set(ctx, value)
+}
+
+func permissionListValue(perms []awscloud.Permission) types.List {
+	values := make([]attr.Value, 0, len(perms))
+	for _, p := range perms {
+		values = append(values, types.ObjectValueMust(permissionAttrTypes, map[string]attr.Value{
+			"effect":    types.StringValue(p.Effect),
+			"principal": types.StringValue(p.Principal),
+			"action":    types.StringValue(p.Action),
+			"resource":  types.StringValue(p.Resource),
+			"condition": types.StringValue(p.Condition),
+		}))
+	}
+	return types.ListValueMust(types.ObjectType{AttrTypes: permissionAttrTypes}, values)
+}
+
+func stringListValue(s []string) types.List {
+	values := make([]attr.Value, 0, len(s))
+	for _, v := range s {
+		values = append(values, types.StringValue(v))
+	}
+	return types.ListValueMust(types.StringType, values)
+}
@@ -0,0 +1,177 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = &PolicyEvaluate{}
+)
+
+var matchedStatementAttrTypes = map[string]attr.Type{
+	"policy_index":    types.Int64Type,
+	"statement_index": types.Int64Type,
+	"sid":             types.StringType,
+	"effect":          types.StringType,
+}
+
+var policyEvaluateAttrTypes = map[string]attr.Type{
+	"decision":   types.StringType,
+	"allowed":    types.BoolType,
+	"sids":       types.ListType{ElemType: types.StringType},
+	"statements": types.ListType{ElemType: types.ObjectType{AttrTypes: matchedStatementAttrTypes}},
+}
+
+func PolicyEvaluateFunction() function.Function {
+	return &PolicyEvaluate{}
+}
+
+type PolicyEvaluate struct{}
+
+func (r PolicyEvaluate) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "policy_evaluate"
+}
+
+// evalParameters are shared by policy_evaluate and policy_allows.
+func evalParameters() []function.Parameter {
+	return []function.Parameter{
+		function.DynamicParameter{
+			Name:        "policies",
+			Description: "A policy in string format, or a list of them",
+		},
+		function.StringParameter{
+			Name:           "principal",
+			Description:    "The principal making the request: an IAM ARN, an account ID or a service principal such as s3.amazonaws.com. If null, Principal elements are ignored, as for identity policies",
+			AllowNullValue: true,
+		},
+		function.StringParameter{
+			Name:        "action",
+			Description: "The action requested, for example s3:GetObject",
+		},
+		function.StringParameter{
+			Name:           "resource",
+			Description:    "The ARN of the resource requested. If null, Resource elements are ignored, as for trust policies",
+			AllowNullValue: true,
+		},
+		function.DynamicParameter{
+			Name:           "context",
+			Description:    "The request context as an object of condition keys to a string or a list of strings, for example { \"aws:SourceVpc\" = \"vpc-1\" }. May be null",
+			AllowNullValue: true,
+		},
+	}
+}
+
+// evalArguments reads the arguments shared by the evaluation functions and evaluates the policies.
+func evalArguments(ctx context.Context, req function.RunRequest) (awscloud.EvalResult, *function.FuncError) {
+	var policiesArg, contextArg types.Dynamic
+	var principal, resource types.String
+	var action string
+
+	funcErr := req.Arguments.Get(ctx, &policiesArg, &principal, &action, &resource, &contextArg)
+	if funcErr != nil {
+		return awscloud.EvalResult{}, funcErr
+	}
+
+	value, err := dynamicToGoType(policiesArg)
+	if err != nil {
+		return awscloud.EvalResult{}, function.NewArgumentFuncError(0, fmt.Sprintf("Error reading policies: %s", err.Error()))
+	}
+	fragments, funcErr := appendPolicyFragments(nil, 0, value)
+	if funcErr != nil {
+		return awscloud.EvalResult{}, funcErr
+	}
+	policies, funcErr := unmarshalPolicyFragments(fragments)
+	if funcErr != nil {
+		return awscloud.EvalResult{}, funcErr
+	}
+
+	evalReq := awscloud.EvalRequest{
+		Principal: principal.ValueString(),
+		Action:    action,
+		Resource:  resource.ValueString(),
+		Context:   map[string][]string{},
+	}
+
+	contextValue, err := dynamicToGoType(contextArg)
+	if err != nil {
+		return awscloud.EvalResult{}, function.NewArgumentFuncError(4, fmt.Sprintf("Error reading context: %s", err.Error()))
+	}
+	if contextValue != nil {
+		contextMap, ok := contextValue.(map[string]any)
+		if !ok {
+			return awscloud.EvalResult{}, function.NewArgumentFuncError(4, "Error reading context: context must be an object")
+		}
+
+		for k, v := range contextMap {
+			switch values := v.(type) {
+			case nil:
+			case []any:
+				for _, e := range values {
+					evalReq.Context[k] = append(evalReq.Context[k], fmt.Sprint(e))
+				}
+			default:
+				evalReq.Context[k] = []string{fmt.Sprint(values)}
+			}
+		}
+	}
+
+	result, err := awscloud.EvaluatePolicies(policies, evalReq)
+	if err != nil {
+		return awscloud.EvalResult{}, function.NewFuncError(fmt.Sprintf("Error evaluating policies: %s", err.Error()))
+	}
+
+	return result, nil
+}
+
+func (f *PolicyEvaluate) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Evaluate IAM policies offline for a request",
+		Description: "Given policies, a principal, an action, a resource and a request context, will return an object with the `decision` (`allow`, `explicit_deny` or `implicit_deny`), `allowed`, the `sids` of the deciding statements and those `statements` as `policy_index`, `statement_index` (zero based), `sid` and `effect`. Follows the IAM evaluation logic for wildcards, NotPrincipal, NotAction, NotResource, policy variables and the common condition operators. No AWS call is made.",
+
+		Parameters: evalParameters(),
+		Return: function.ObjectReturn{
+			AttributeTypes: policyEvaluateAttrTypes,
+		},
+	}
+}
+
+func (f *PolicyEvaluate) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	result, funcErr := evalArguments(ctx, req)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	statements := make([]attr.Value, 0, len(result.Matched))
+	for _, m := range result.Matched {
+		statements = append(statements, types.ObjectValueMust(matchedStatementAttrTypes, map[string]attr.Value{
+			"policy_index":    types.Int64Value(int64(m.Policy)),
+			"statement_index": types.Int64Value(int64(m.Statement)),
+			"sid":             types.StringValue(m.Sid),
+			"effect":          types.StringValue(m.Effect),
+		}))
+	}
+
+	sids := make([]attr.Value, 0, len(result.Matched))
+	for _, sid := range result.Sids() {
+		sids = append(sids, types.StringValue(sid))
+	}
+
+	value := types.ObjectValueMust(policyEvaluateAttrTypes, map[string]attr.Value{
+		"decision":   types.StringValue(result.Decision),
+		"allowed":    types.BoolValue(result.Decision == awscloud.DecisionAllow),
+		"sids":       types.ListValueMust(types.StringType, sids),
+		"statements": types.ListValueMust(types.ObjectType{AttrTypes: matchedStatementAttrTypes}, statements),
+	})
+
+	resp.Error = resp.Result.Set(ctx, value)
+}
@@ -0,0 +1,92 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = &LintPolicy{}
+)
+
+var findingAttrTypes = map[string]attr.Type{
+	"rule":            types.StringType,
+	"severity":        types.StringType,
+	"statement_index": types.Int64Type,
+	"sid":             types.StringType,
+	"message":         types.StringType,
+}
+
+func LintPolicyFunction() function.Function {
+	return &LintPolicy{}
+}
+
+type LintPolicy struct{}
+
+func (r LintPolicy) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "lint_policy"
+}
+
+func (f *LintPolicy) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Return security findings for an IAM policy",
+		Description: "Given an IAM policy JSON string and its type, will return a list of findings with `rule`, `severity` (`high`, `medium` or `low`), `statement_index` (zero based, -1 for the whole policy), `sid` and `message`. Rules: `wildcard_action_resource`, `public_principal`, `passrole_wildcard`, and for bucket policies `missing_secure_transport`, for KMS key policies `kms_lockout_risk`. No AWS call is made.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "policy",
+				Description: "Policy in string format",
+			},
+			function.StringParameter{
+				Name:        "policy_type",
+				Description: "The kind of policy: identity, bucket, kms, or auto to guess it from the statements",
+			},
+		},
+		Return: function.ListReturn{
+			ElementType: types.ObjectType{AttrTypes: findingAttrTypes},
+		},
+	}
+}
+
+func (f *LintPolicy) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var policyJSON, policyType string
+
+	resp.Error = req.Arguments.Get(ctx, &policyJSON, &policyType)
+	if resp.Error != nil {
+		return
+	}
+
+	var policy awscloud.Policy
+	if err := json.Unmarshal([]byte(policyJSON), &policy); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error unmarshalling policy: %s", err.Error()))
+		return
+	}
+
+	findings, err := awscloud.LintPolicy(policy, policyType)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Error reading policy_type: %s", err.Error()))
+		return
+	}
+
+	values := make([]attr.Value, 0, len(findings))
+	for _, finding := range findings {
+		values = append(values, types.ObjectValueMust(findingAttrTypes, map[string]attr.Value{
+			"rule":            types.StringValue(finding.Rule),
+			"severity":        types.StringValue(finding.Severity),
+			"statement_index": types.Int64Value(int64(finding.StatementIndex)),
+			"sid":             types.StringValue(finding.Sid),
+			"message":         types.StringValue(finding.Message),
+		}))
+	}
+
+	resp.Error = resp.Result.Set(ctx, types.ListValueMust(types.ObjectType{AttrTypes: findingAttrTypes}, values))
+}
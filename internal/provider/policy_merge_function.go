@@ -10,8 +10,8 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 var (
@@ -30,66 +30,160 @@ func (r MergePolicy) Metadata(_ context.Context, req function.MetadataRequest, r
 
 func (f *MergePolicy) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
 	resp.Definition = function.Definition{
-		Summary:     "Return a list of files/folders given a directory path or empty list if there are any errors",
-		Description: "Given a directory path, will return a list of (one level only) files and folders in that directory. Returns an empty list if the directory does not exist or is not accessible.",
+		Summary:     "Merge IAM policy documents into one",
+		Description: "Given any number of IAM policy JSON strings, or lists of them, will return a single policy JSON string. Statements matching an earlier statement are combined, others are appended. The last argument may be an options object with `match` (`principal_resource` (default), `principal_resource_effect_condition` or `sid`), `on_sid_conflict` (`error` (default), `rename` or `override`), `merge_deny` (default false), `merge_incompatible_conditions` (default false) and `actions` (`expand` to replace wildcard actions with the actions they match, or `compact` to turn action lists back into wildcards, using the bundled action catalog; `NotAction`, `*` and the actions of services the catalog does not list completely are kept as written, so neither changes what the policy allows).",
 
 		Parameters: []function.Parameter{
-			function.StringParameter{
-				Name:        "existing_policy",
-				Description: "Existing policy in string format",
-			},
-			function.StringParameter{
-				Name:        "new_policy",
-				Description: "New policy in string format to merge with the existing policy",
+			function.DynamicParameter{
+				Name:        "policies",
+				Description: "A policy in string format, or a list of them",
 			},
 		},
+		VariadicParameter: function.DynamicParameter{
+			Name:        "more_policies",
+			Description: "More policies in string format or lists of them to merge, optionally followed by an options object",
+		},
 		Return: function.StringReturn{},
 	}
 }
 
+// policyFragment is a policy argument together with a label naming where it came from.
+type policyFragment struct {
+	argument int
+	label    string
+	document string
+}
+
 func (f *MergePolicy) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
-	var existingPolicy string
-	var newPolicy string
+	var first types.Dynamic
+	var more []types.Dynamic
 
-	resp.Error = req.Arguments.Get(ctx, &existingPolicy, &newPolicy)
+	resp.Error = req.Arguments.Get(ctx, &first, &more)
 	if resp.Error != nil {
 		return
 	}
 
-	var policy1, policy2 awscloud.Policy
+	args := append([]types.Dynamic{first}, more...)
+
+	var fragments []policyFragment
+	var opts awscloud.MergeOptions
+
+	for i, arg := range args {
+		value, err := dynamicToGoType(arg)
+		if err != nil {
+			resp.Error = function.NewArgumentFuncError(int64(i), fmt.Sprintf("Error reading argument %d: %s", i+1, err.Error()))
+			return
+		}
+
+		if m, ok := value.(map[string]any); ok {
+			if i != len(args)-1 {
+				resp.Error = function.NewArgumentFuncError(int64(i), "Error reading options: the options object must be the last argument")
+				return
+			}
+			opts, err = mergeOptionsFromMap(m)
+			if err != nil {
+				resp.Error = function.NewArgumentFuncError(int64(i), fmt.Sprintf("Error reading options: %s", err.Error()))
+				return
+			}
+			continue
+		}
+
+		fragments, resp.Error = appendPolicyFragments(fragments, i, value)
+		if resp.Error != nil {
+			return
+		}
+	}
 
-	err := json.Unmarshal([]byte(existingPolicy), &policy1)
-	if err != nil {
-		resp.Error = function.FuncErrorFromDiags(ctx, diag.Diagnostics{
-			diag.NewErrorDiagnostic("Policy Unmarshal Error", fmt.Sprintf("Error unmarshalling policy 1: %s", err.Error())),
-		})
+	policies, funcErr := unmarshalPolicyFragments(fragments)
+	if funcErr != nil {
+		resp.Error = funcErr
 		return
 	}
-	err = json.Unmarshal([]byte(newPolicy), &policy2)
+
+	mergedPolicy, err := awscloud.MergePolicies(opts, policies...)
 	if err != nil {
-		resp.Error = function.FuncErrorFromDiags(ctx, diag.Diagnostics{
-			diag.NewErrorDiagnostic("Policy Unmarshal Error", fmt.Sprintf("Error unmarshalling policy 2: %s", err.Error())),
-		})
+		resp.Error = function.NewFuncError(fmt.Sprintf("Merge Policy Error: %s", err.Error()))
 		return
 	}
 
-	mergedPolicy := awscloud.MergePolicies(policy1, policy2)
-
 	mergedJSON, err := json.Marshal(mergedPolicy)
 	if err != nil {
-		resp.Error = function.FuncErrorFromDiags(ctx, diag.Diagnostics{
-			diag.NewErrorDiagnostic("Marshalling Error", fmt.Sprintf("Error marshalling merged policy: %s", err.Error())),
-		})
+		resp.Error = function.NewFuncError(fmt.Sprintf("Error marshalling merged policy: %s", err.Error()))
 		return
 	}
 
-	if string(mergedJSON) == "" {
-		resp.Error = function.FuncErrorFromDiags(ctx, diag.Diagnostics{
-			diag.NewErrorDiagnostic("Merge Policy Error", "Failed to merge policies! Check existing_policy and statements arguments."),
-		})
-		return
+	resp.Error = resp.Result.Set(ctx, string(mergedJSON))
+}
+
+// appendPolicyFragments adds the policy string, or list of policy strings, passed as argument i.
+func appendPolicyFragments(fragments []policyFragment, i int, value any) ([]policyFragment, *function.FuncError) {
+	switch v := value.(type) {
+	case nil:
+	case string:
+		fragments = append(fragments, policyFragment{argument: i, label: fmt.Sprintf("policy %d (argument %d)", len(fragments)+1, i+1), document: v})
+	case []any:
+		for j, e := range v {
+			if e == nil {
+				continue
+			}
+			document, ok := e.(string)
+			if !ok {
+				return nil, function.NewArgumentFuncError(int64(i), fmt.Sprintf("Error reading argument %d: element %d must be a policy string", i+1, j+1))
+			}
+			fragments = append(fragments, policyFragment{argument: i, label: fmt.Sprintf("policy %d (argument %d, element %d)", len(fragments)+1, i+1, j+1), document: document})
+		}
+	default:
+		return nil, function.NewArgumentFuncError(int64(i), fmt.Sprintf("Error reading argument %d: expected a policy string or a list of policy strings", i+1))
 	}
 
-	resp.Error = resp.Result.Set(ctx, string(mergedJSON))
+	return fragments, nil
+}
+
+// unmarshalPolicyFragments parses every fragment, naming the one that fails.
+func unmarshalPolicyFragments(fragments []policyFragment) ([]awscloud.Policy, *function.FuncError) {
+	if len(fragments) == 0 {
+		return nil, function.NewFuncError("Error reading policies: no policies given")
+	}
+
+	policies := make([]awscloud.Policy, len(fragments))
+	for n, fragment := range fragments {
+		if err := json.Unmarshal([]byte(fragment.document), &policies[n]); err != nil {
+			return nil, function.NewArgumentFuncError(int64(fragment.argument), fmt.Sprintf("Error unmarshalling %s: %s", fragment.label, err.Error()))
+		}
+	}
+
+	return policies, nil
+}
+
+// mergeOptionsFromMap reads the merge_policy options object.
+func mergeOptionsFromMap(m map[string]any) (awscloud.MergeOptions, error) {
+	var opts awscloud.MergeOptions
+
+	for k, v := range m {
+		if v == nil {
+			continue
+		}
+
+		var ok bool
+		switch k {
+		case "match":
+			opts.Match, ok = v.(string)
+		case "on_sid_conflict":
+			opts.OnSidConflict, ok = v.(string)
+		case "merge_deny":
+			opts.MergeDeny, ok = v.(bool)
+		case "merge_incompatible_conditions":
+			opts.MergeIncompatibleConditions, ok = v.(bool)
+		case "actions":
+			opts.Actions, ok = v.(string)
+		default:
+			return opts, fmt.Errorf("unsupported option %q", k)
+		}
+
+		if !ok {
+			return opts, fmt.Errorf("option %s has the wrong type %T", k, v)
+		}
+	}
 
+	return opts, nil
 }
@@ -0,0 +1,65 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var (
+	_ function.Function = &NormalizePolicy{}
+)
+
+func NormalizePolicyFunction() function.Function {
+	return &NormalizePolicy{}
+}
+
+type NormalizePolicy struct{}
+
+func (r NormalizePolicy) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "normalize_policy"
+}
+
+func (f *NormalizePolicy) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Return the canonical form of an IAM policy",
+		Description: "Given an IAM policy JSON string, will return it with duplicate statements and values removed, actions covered by a wildcard action of the same statement dropped, every list sorted and one element lists written as strings. The output is stable, which avoids perpetual diffs on policy attributes.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "policy",
+				Description: "Policy in string format",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *NormalizePolicy) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var policyJSON string
+
+	resp.Error = req.Arguments.Get(ctx, &policyJSON)
+	if resp.Error != nil {
+		return
+	}
+
+	var policy awscloud.Policy
+	if err := json.Unmarshal([]byte(policyJSON), &policy); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error unmarshalling policy: %s", err.Error()))
+		return
+	}
+
+	normalizedJSON, err := json.Marshal(awscloud.NormalizePolicy(policy))
+	if err != nil {
+		resp.Error = function.NewFuncError(fmt.Sprintf("Error marshalling policy: %s", err.Error()))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, string(normalizedJSON))
+}
@@ -0,0 +1,88 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = &SplitPolicy{}
+)
+
+func SplitPolicyFunction() function.Function {
+	return &SplitPolicy{}
+}
+
+type SplitPolicy struct{}
+
+func (r SplitPolicy) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "split_policy"
+}
+
+func (f *SplitPolicy) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Split an IAM policy into documents that fit a size limit",
+		Description: "Given an IAM policy JSON string and a limit kind, `managed` (6144 characters), `role_inline` (10240) or `bucket` (20480), will return a list of minified policy JSON strings, each under the limit. Statements are bin-packed into as few documents as possible and a statement too large on its own has its actions split across several statements. The result is deterministic.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "policy",
+				Description: "Policy in string format",
+			},
+			function.StringParameter{
+				Name:        "limit_kind",
+				Description: "The size limit to fit: managed, role_inline or bucket",
+			},
+		},
+		Return: function.ListReturn{
+			ElementType: types.StringType,
+		},
+	}
+}
+
+func (f *SplitPolicy) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var policyJSON, limitKind string
+
+	resp.Error = req.Arguments.Get(ctx, &policyJSON, &limitKind)
+	if resp.Error != nil {
+		return
+	}
+
+	limit, ok := awscloud.PolicySizeLimits[limitKind]
+	if !ok {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Error reading limit_kind: %q is not one of managed, role_inline or bucket", limitKind))
+		return
+	}
+
+	var policy awscloud.Policy
+	if err := json.Unmarshal([]byte(policyJSON), &policy); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error unmarshalling policy: %s", err.Error()))
+		return
+	}
+
+	docs, err := awscloud.SplitPolicy(policy, limit)
+	if err != nil {
+		resp.Error = function.NewFuncError(fmt.Sprintf("Error splitting policy: %s", err.Error()))
+		return
+	}
+
+	docsJSON := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		encoded, err := json.Marshal(doc)
+		if err != nil {
+			resp.Error = function.NewFuncError(fmt.Sprintf("Error marshalling policy: %s", err.Error()))
+			return
+		}
+		docsJSON = append(docsJSON, string(encoded))
+	}
+
+	resp.Error = resp.Result.Set(ctx, docsJSON)
+}
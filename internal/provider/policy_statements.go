@@ -0,0 +1,138 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ownedStatements parses the policy attribute of the statement resources.
+func ownedStatements(document string) (awscloud.Policy, error) {
+	var policy awscloud.Policy
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		return policy, fmt.Errorf("policy is not a valid policy document: %w", err)
+	}
+	return policy, nil
+}
+
+// applyOwnedStatements writes the statements of the policy attribute to the store, releasing the previously
+// owned Sids that are no longer in it, and returns the Sids now owned.
+func applyOwnedStatements(ctx context.Context, store awscloud.PolicyStore, document string, prior types.List) (types.List, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	policy, err := ownedStatements(document)
+	if err != nil {
+		diags.AddAttributeError(path.Root("policy"), "Invalid policy", err.Error())
+		return types.ListNull(types.StringType), diags
+	}
+
+	var owned []string
+	if !prior.IsNull() && !prior.IsUnknown() {
+		diags.Append(prior.ElementsAs(ctx, &owned, false)...)
+		if diags.HasError() {
+			return types.ListNull(types.StringType), diags
+		}
+	}
+
+	if err := awscloud.ApplyPolicyStatements(ctx, store, policy.Statement, owned); err != nil {
+		diags.AddError("Error", fmt.Sprint("Unable to update policy statements...", err))
+		return types.ListNull(types.StringType), diags
+	}
+
+	sids := make([]string, 0, len(policy.Statement))
+	for _, s := range policy.Statement {
+		sids = append(sids, s.Sid)
+	}
+
+	sidsValue, d := types.ListValueFrom(ctx, types.StringType, sids)
+	diags.Append(d...)
+	return sidsValue, diags
+}
+
+// refreshOwnedStatements compares the owned statements in the store with the policy attribute. The policy is
+// rewritten only when statements were removed or changed outside Terraform, so formatting stays as written.
+func refreshOwnedStatements(ctx context.Context, store awscloud.PolicyStore, document string, sids types.List) (string, types.List, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	var owned []string
+	diags.Append(sids.ElementsAs(ctx, &owned, false)...)
+	if diags.HasError() {
+		return document, sids, diags
+	}
+
+	current, err := store.GetPolicy(ctx)
+	if err != nil {
+		diags.AddError("Error", fmt.Sprint("Unable to read policy...", err))
+		return document, sids, diags
+	}
+
+	var remote awscloud.Policy
+	if current != "" {
+		if err := json.Unmarshal([]byte(current), &remote); err != nil {
+			diags.AddError("Error", fmt.Sprint("Unable to parse the current policy...", err))
+			return document, sids, diags
+		}
+	}
+
+	found := make(map[string]awscloud.Statement)
+	for _, s := range awscloud.OwnedStatements(remote, owned) {
+		found[s.Sid] = s
+	}
+
+	policy, err := ownedStatements(document)
+	if err != nil {
+		diags.AddAttributeError(path.Root("policy"), "Invalid policy", err.Error())
+		return document, sids, diags
+	}
+
+	changed := false
+	refreshed := policy
+	refreshed.Statement = nil
+	for _, s := range policy.Statement {
+		r, ok := found[s.Sid]
+		if !ok {
+			changed = true
+			continue
+		}
+		if !sameStatement(s, r) {
+			changed = true
+			s = r
+		}
+		refreshed.Statement = append(refreshed.Statement, s)
+	}
+
+	if !changed {
+		return document, sids, diags
+	}
+
+	encoded, err := json.Marshal(refreshed)
+	if err != nil {
+		diags.AddError("Error", fmt.Sprint("Unable to encode policy...", err))
+		return document, sids, diags
+	}
+
+	remaining := make([]string, 0, len(refreshed.Statement))
+	for _, s := range refreshed.Statement {
+		remaining = append(remaining, s.Sid)
+	}
+
+	sidsValue, d := types.ListValueFrom(ctx, types.StringType, remaining)
+	diags.Append(d...)
+	return string(encoded), sidsValue, diags
+}
+
+// sameStatement compares statements after normalization, so reordered or reformatted values are equal.
+func sameStatement(a, b awscloud.Statement) bool {
+	na, errA := json.Marshal(awscloud.NormalizePolicy(awscloud.Policy{Statement: []awscloud.Statement{a}}))
+	nb, errB := json.Marshal(awscloud.NormalizePolicy(awscloud.Policy{Statement: []awscloud.Statement{b}}))
+	return errA == nil && errB == nil && bytes.Equal(na, nb)
+}
@@ -0,0 +1,229 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &PolicyStatementsResource{}
+
+// PolicyStatementsResource owns statements of the resource policy of a single target, a bucket or a key,
+// read and written through the PolicyStore newStore returns for it.
+type PolicyStatementsResource struct {
+	cfg aws.Config
+
+	// typeName is appended to the provider type name.
+	typeName string
+	// target is the attribute naming the bucket or key, and targetKind names it in messages.
+	target      string
+	targetKind  string
+	description string
+	// targetDescription, regionDescription and policyDescription describe the attributes.
+	targetDescription string
+	regionDescription string
+	policyDescription string
+
+	newStore func(cfg aws.Config, target string) awscloud.PolicyStore
+}
+
+// PolicyStatementsResourceModel describes the resource data model, the target attribute being named by the resource.
+type PolicyStatementsResourceModel struct {
+	Target types.String
+	Region types.String
+	Policy types.String
+	Sids   types.List
+}
+
+func (r *PolicyStatementsResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_" + r.typeName
+}
+
+func (r *PolicyStatementsResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: r.description,
+		Attributes: map[string]schema.Attribute{
+			r.target: schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: r.targetDescription,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"region": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: r.regionDescription,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"policy": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: r.policyDescription,
+			},
+			"sids": schema.ListAttribute{
+				Computed:            true,
+				ElementType:         types.StringType,
+				MarkdownDescription: "Sids of the statements owned by this resource",
+			},
+		},
+	}
+}
+
+func (r *PolicyStatementsResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	cfg, ok := req.ProviderData.(aws.Config)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *aws.Config, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.cfg = cfg
+}
+
+// attributeGetter is the part of tfsdk.Plan and tfsdk.State the model is read from.
+type attributeGetter interface {
+	GetAttribute(ctx context.Context, p path.Path, target interface{}) diag.Diagnostics
+}
+
+// get reads the model attribute by attribute, as the name of the target attribute differs between resources.
+func (r *PolicyStatementsResource) get(ctx context.Context, from attributeGetter) (PolicyStatementsResourceModel, diag.Diagnostics) {
+	var m PolicyStatementsResourceModel
+	var diags diag.Diagnostics
+
+	diags.Append(from.GetAttribute(ctx, path.Root(r.target), &m.Target)...)
+	diags.Append(from.GetAttribute(ctx, path.Root("region"), &m.Region)...)
+	diags.Append(from.GetAttribute(ctx, path.Root("policy"), &m.Policy)...)
+	diags.Append(from.GetAttribute(ctx, path.Root("sids"), &m.Sids)...)
+	return m, diags
+}
+
+func (r *PolicyStatementsResource) set(ctx context.Context, state *tfsdk.State, m PolicyStatementsResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	diags.Append(state.SetAttribute(ctx, path.Root(r.target), m.Target)...)
+	diags.Append(state.SetAttribute(ctx, path.Root("region"), m.Region)...)
+	diags.Append(state.SetAttribute(ctx, path.Root("policy"), m.Policy)...)
+	diags.Append(state.SetAttribute(ctx, path.Root("sids"), m.Sids)...)
+	return diags
+}
+
+func (r *PolicyStatementsResource) store(m PolicyStatementsResourceModel) awscloud.PolicyStore {
+	cfg := r.cfg
+	if m.Region.ValueString() != "" {
+		cfg = cfg.Copy()
+		cfg.Region = m.Region.ValueString()
+	}
+	return r.newStore(cfg, m.Target.ValueString())
+}
+
+func (r *PolicyStatementsResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	plan, diags := r.get(ctx, req.Plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sids, diags := applyOwnedStatements(ctx, r.store(plan), plan.Policy.ValueString(), types.ListNull(types.StringType))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plan.Sids = sids
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(r.set(ctx, &resp.State, plan)...)
+}
+
+func (r *PolicyStatementsResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Read Terraform prior state data into the model
+	state, diags := r.get(ctx, req.State)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	store := r.store(state)
+	if _, err := store.GetPolicy(ctx); errors.Is(err, awscloud.ErrPolicyTargetNotFound) {
+		tflog.Warn(ctx, fmt.Sprintf("%s %s not found, removing from state", r.targetKind, state.Target.ValueString()))
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	policy, sids, diags := refreshOwnedStatements(ctx, store, state.Policy.ValueString(), state.Sids)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	state.Policy = types.StringValue(policy)
+	state.Sids = sids
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(r.set(ctx, &resp.State, state)...)
+}
+
+func (r *PolicyStatementsResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	plan, diags := r.get(ctx, req.Plan)
+	resp.Diagnostics.Append(diags...)
+	state, diags := r.get(ctx, req.State)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sids, diags := applyOwnedStatements(ctx, r.store(plan), plan.Policy.ValueString(), state.Sids)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plan.Sids = sids
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(r.set(ctx, &resp.State, plan)...)
+}
+
+func (r *PolicyStatementsResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	state, diags := r.get(ctx, req.State)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// only remove the statements this resource owns.
+	var owned []string
+	resp.Diagnostics.Append(state.Sids.ElementsAs(ctx, &owned, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := awscloud.ApplyPolicyStatements(ctx, r.store(state), nil, owned)
+	if err != nil && !errors.Is(err, awscloud.ErrPolicyTargetNotFound) {
+		resp.Diagnostics.AddError("Error", fmt.Sprint("Unable to remove policy statements...", err))
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// memoryPolicyStore keeps the policy of each target in memory.
+type memoryPolicyStore struct {
+	policies map[string]string
+	target   string
+}
+
+func (s *memoryPolicyStore) GetPolicy(ctx context.Context) (string, error) {
+	return s.policies[s.target], nil
+}
+
+func (s *memoryPolicyStore) PutPolicy(ctx context.Context, policy string) error {
+	s.policies[s.target] = policy
+	return nil
+}
+
+func (s *memoryPolicyStore) DeletePolicy(ctx context.Context) error {
+	delete(s.policies, s.target)
+	return nil
+}
+
+func TestPolicyStatementsResource(t *testing.T) {
+	ctx := context.Background()
+	other := `{"Sid":"Other","Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*"}`
+	policies := map[string]string{"b": `{"Version":"2012-10-17","Statement":[` + other + `]}`}
+
+	for _, newResource := range []func() resource.Resource{NewS3BucketPolicyStatementsResource, NewKmsKeyPolicyStatementsResource} {
+		r := newResource().(*PolicyStatementsResource)
+		r.newStore = func(_ aws.Config, target string) awscloud.PolicyStore {
+			return &memoryPolicyStore{policies: policies, target: target}
+		}
+
+		var schemaResp resource.SchemaResponse
+		r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+		objectType := schemaResp.Schema.Type().TerraformType(ctx)
+
+		owned := `{"Statement":[{"Sid":"Mine","Effect":"Allow","Principal":"*","Action":"s3:PutObject","Resource":"arn:aws:s3:::b/*"}]}`
+		plan := tfsdk.Plan{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, map[string]tftypes.Value{
+			r.target: tftypes.NewValue(tftypes.String, "b"),
+			"region": tftypes.NewValue(tftypes.String, nil),
+			"policy": tftypes.NewValue(tftypes.String, owned),
+			"sids":   tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, tftypes.UnknownValue),
+		})}
+
+		createResp := resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}}
+		r.Create(ctx, resource.CreateRequest{Plan: plan}, &createResp)
+		if createResp.Diagnostics.HasError() {
+			t.Fatalf("%s: Create() diagnostics = %v", r.typeName, createResp.Diagnostics)
+		}
+
+		var target types.String
+		var sids []string
+		createResp.State.GetAttribute(ctx, path.Root(r.target), &target)
+		createResp.State.GetAttribute(ctx, path.Root("sids"), &sids)
+		if target.ValueString() != "b" || len(sids) != 1 || sids[0] != "Mine" {
+			t.Errorf("%s: Create() state %s = %s, sids = %v", r.typeName, r.target, target, sids)
+		}
+
+		var stored awscloud.Policy
+		_ = json.Unmarshal([]byte(policies["b"]), &stored)
+		if len(stored.Statement) != 2 {
+			t.Errorf("%s: stored policy = %s, want Other and Mine", r.typeName, policies["b"])
+		}
+
+		deleteResp := resource.DeleteResponse{State: createResp.State}
+		r.Delete(ctx, resource.DeleteRequest{State: createResp.State}, &deleteResp)
+		if deleteResp.Diagnostics.HasError() {
+			t.Fatalf("%s: Delete() diagnostics = %v", r.typeName, deleteResp.Diagnostics)
+		}
+		_ = json.Unmarshal([]byte(policies["b"]), &stored)
+		if len(stored.Statement) != 1 || stored.Statement[0].Sid != "Other" {
+			t.Errorf("%s: policy after Delete() = %s, want only Other", r.typeName, policies["b"])
+		}
+	}
+}
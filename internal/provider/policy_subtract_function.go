@@ -0,0 +1,123 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = &SubtractPolicy{}
+)
+
+func SubtractPolicyFunction() function.Function {
+	return &SubtractPolicy{}
+}
+
+type SubtractPolicy struct{}
+
+func (r SubtractPolicy) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "subtract_policy"
+}
+
+func (f *SubtractPolicy) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Remove statements from an IAM policy",
+		Description: "Given a base IAM policy JSON string and the policies to remove, will return the base policy without them. Statements covered in full are dropped; statements that only partly match lose the removed actions, resources or principals, and are dropped once empty. Statements match when their effect and conditions are the same. An options object may follow, with `match` as for merge_policy: `sid` also requires the same Sid.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "base",
+				Description: "Policy in string format to remove statements from",
+			},
+			function.DynamicParameter{
+				Name:        "remove",
+				Description: "A policy in string format, or a list of them, whose statements are removed",
+			},
+		},
+		VariadicParameter: function.DynamicParameter{
+			Name:        "options",
+			Description: "An optional options object, with `match` as for merge_policy",
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *SubtractPolicy) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var baseJSON string
+	var removeArg types.Dynamic
+	var optionArgs []types.Dynamic
+
+	resp.Error = req.Arguments.Get(ctx, &baseJSON, &removeArg, &optionArgs)
+	if resp.Error != nil {
+		return
+	}
+
+	var base awscloud.Policy
+	if err := json.Unmarshal([]byte(baseJSON), &base); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error unmarshalling base policy: %s", err.Error()))
+		return
+	}
+
+	value, err := dynamicToGoType(removeArg)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Error reading remove: %s", err.Error()))
+		return
+	}
+	fragments, funcErr := appendPolicyFragments(nil, 1, value)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+	removed, funcErr := unmarshalPolicyFragments(fragments)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	var opts awscloud.MergeOptions
+	if len(optionArgs) > 1 {
+		resp.Error = function.NewArgumentFuncError(3, "Error reading options: only one options object is accepted")
+		return
+	}
+	for _, optionArg := range optionArgs {
+		optionValue, err := dynamicToGoType(optionArg)
+		if err != nil {
+			resp.Error = function.NewArgumentFuncError(2, fmt.Sprintf("Error reading options: %s", err.Error()))
+			return
+		}
+		optionMap, ok := optionValue.(map[string]any)
+		if optionValue != nil && !ok {
+			resp.Error = function.NewArgumentFuncError(2, "Error reading options: options must be an object")
+			return
+		}
+		opts, err = mergeOptionsFromMap(optionMap)
+		if err != nil {
+			resp.Error = function.NewArgumentFuncError(2, fmt.Sprintf("Error reading options: %s", err.Error()))
+			return
+		}
+	}
+
+	for _, policy := range removed {
+		base, err = awscloud.SubtractPolicies(base, policy, opts)
+		if err != nil {
+			resp.Error = function.NewFuncError(fmt.Sprintf("Subtract Policy Error: %s", err.Error()))
+			return
+		}
+	}
+
+	resultJSON, err := json.Marshal(base)
+	if err != nil {
+		resp.Error = function.NewFuncError(fmt.Sprintf("Error marshalling policy: %s", err.Error()))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, string(resultJSON))
+}
@@ -0,0 +1,77 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = &PolicySummary{}
+)
+
+func PolicySummaryFunction() function.Function {
+	return &PolicySummary{}
+}
+
+type PolicySummary struct{}
+
+func (r PolicySummary) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "policy_summary"
+}
+
+func (f *PolicySummary) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Return a human readable summary of an IAM policy",
+		Description: "Given an IAM policy JSON string and a format, will return who can do what under the policy as Markdown tables or plain text: statements are grouped by effect and principal, actions are listed by access level with their resources, and conditions are described in English, e.g. only over TLS or only from vpce-123.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "policy",
+				Description: "Policy in string format",
+			},
+			function.StringParameter{
+				Name:           "format",
+				Description:    "markdown or text. If null, defaults to markdown",
+				AllowNullValue: true,
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *PolicySummary) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var policyJSON string
+	var format types.String
+
+	resp.Error = req.Arguments.Get(ctx, &policyJSON, &format)
+	if resp.Error != nil {
+		return
+	}
+
+	var policy awscloud.Policy
+	if err := json.Unmarshal([]byte(policyJSON), &policy); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error unmarshalling policy: %s", err.Error()))
+		return
+	}
+
+	summaryFormat := awscloud.SummaryFormatMarkdown
+	if !format.IsNull() {
+		summaryFormat = format.ValueString()
+	}
+
+	summary, err := awscloud.SummarizePolicy(policy, summaryFormat)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Error summarizing policy: %s", err.Error()))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, summary)
+}
@@ -86,6 +86,9 @@ func (p *AWSUtilsProvider) Resources(ctx context.Context) []func() resource.Reso
 		NewS3UploadResource,
 		NewOpenAPIMergeResource,
 		NewRunCommandResource,
+		NewCfKvsSyncResource,
+		NewS3BucketPolicyStatementsResource,
+		NewKmsKeyPolicyStatementsResource,
 	}
 }
 
@@ -100,16 +103,35 @@ func (p *AWSUtilsProvider) DataSources(ctx context.Context) []func() datasource.
 		NewExternalDataSource,
 		NewExecfileDataSource,
 		NewRdsDataExecute,
+		NewCloudFrontDistributionDataSource,
+		NewResourcePolicyDataSource,
 	}
 }
 
 func (p *AWSUtilsProvider) Functions(ctx context.Context) []func() function.Function {
 	return []func() function.Function{
 		NewAwsVarFunction,
+		SubDataDynamicFunction,
 		ShallowListFunction,
 		MergePolicyFunction,
+		NormalizePolicyFunction,
+		PolicyAllowsFunction,
+		PolicyEvaluateFunction,
+		PolicyDiffFunction,
+		LintPolicyFunction,
+		SplitPolicyFunction,
+		SubtractPolicyFunction,
+		ArnParseFunction,
+		ArnBuildFunction,
+		ArnMatchFunction,
+		ExpandActionsFunction,
+		ActionAccessLevelFunction,
+		TrustPolicyFunction,
+		PolicySummaryFunction,
 		FileSetFunction,
 		FileTreeFunction,
+		CloudFrontSignedURLFunction,
+		CloudFrontSignedCookiesFunction,
 	}
 }
 
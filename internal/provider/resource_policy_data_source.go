@@ -0,0 +1,164 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &resourcePolicyDataSource{}
+	_ datasource.DataSourceWithConfigure = &resourcePolicyDataSource{}
+)
+
+// NewResourcePolicyDataSource is a helper function to simplify the provider implementation.
+func NewResourcePolicyDataSource() datasource.DataSource {
+	return &resourcePolicyDataSource{}
+}
+
+// resourcePolicyDataSource is the data source implementation.
+type resourcePolicyDataSource struct {
+	cfg aws.Config
+}
+
+// resourcePolicyDataSourceModel describes the data source data model.
+type resourcePolicyDataSourceModel struct {
+	Arn          types.String  `tfsdk:"arn"`
+	Strict       types.Bool    `tfsdk:"strict"`
+	Service      types.String  `tfsdk:"service"`
+	Policy       types.String  `tfsdk:"policy"`
+	PolicyObject types.Dynamic `tfsdk:"policy_object"`
+}
+
+// Metadata returns the data source type name.
+func (d *resourcePolicyDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_resource_policy"
+}
+
+// Schema defines the schema for the data source.
+func (d *resourcePolicyDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Retrieves the resource based policy of an AWS resource by ARN. Supports " + strings.Join(awscloud.ResourcePolicyServices, ", ") + " ARNs.",
+		Attributes: map[string]schema.Attribute{
+			"arn": schema.StringAttribute{
+				Required:    true,
+				Description: "The ARN of the resource. Its region, when present, overrides the provider region.",
+			},
+			"strict": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "If true, will throw an error if the policy is not found. Defaults to false.",
+			},
+			"service": schema.StringAttribute{
+				Computed:    true,
+				Description: "The service part of the ARN the policy was fetched for.",
+			},
+			"policy": schema.StringAttribute{
+				Computed:    true,
+				Description: "The resource policy in JSON format, or an empty string when not found and strict is false.",
+			},
+			"policy_object": schema.DynamicAttribute{
+				Computed:    true,
+				Description: "The resource policy decoded into an object, or null when not found and strict is false.",
+			},
+		},
+	}
+}
+
+func (d *resourcePolicyDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	cfg, ok := req.ProviderData.(aws.Config)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *aws.Config, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.cfg = cfg
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *resourcePolicyDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state resourcePolicyDataSourceModel
+
+	// Get configuration
+	resp.Diagnostics.Append(req.Config.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if state.Strict.IsNull() || state.Strict.IsUnknown() {
+		state.Strict = types.BoolValue(false)
+	}
+
+	resourceARN := state.Arn.ValueString()
+
+	parsed, err := arn.Parse(resourceARN)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("arn"), "Invalid ARN", err.Error())
+		return
+	}
+	state.Service = types.StringValue(parsed.Service)
+
+	policy, err := awscloud.GetResourcePolicy(ctx, d.cfg, resourceARN)
+	if err != nil {
+		if state.Strict.ValueBool() {
+			resp.Diagnostics.AddError(
+				"Error retrieving resource policy",
+				"Unable to retrieve the resource policy for "+resourceARN+": "+err.Error(),
+			)
+			return
+		}
+
+		resp.Diagnostics.AddWarning(
+			"Error retrieving resource policy",
+			"Unable to retrieve the resource policy for "+resourceARN+": "+err.Error(),
+		)
+		state.Policy = types.StringValue("")
+		state.PolicyObject = types.DynamicNull()
+		resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+		return
+	}
+
+	var decoded any
+	if err := json.Unmarshal([]byte(policy), &decoded); err != nil {
+		resp.Diagnostics.AddError(
+			"Error decoding resource policy",
+			"The resource policy for "+resourceARN+" is not valid JSON: "+err.Error(),
+		)
+		return
+	}
+
+	policyObject, diags := decodeAny(ctx, decoded)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Policy = types.StringValue(policy)
+	state.PolicyObject = types.DynamicValue(policyObject)
+
+	// Set the new state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
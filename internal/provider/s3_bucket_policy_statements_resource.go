@@ -0,0 +1,23 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+)
+
+func NewS3BucketPolicyStatementsResource() resource.Resource {
+	return &PolicyStatementsResource{
+		typeName:          "s3_bucket_policy_statements",
+		target:            "bucket",
+		targetKind:        "Bucket",
+		description:       "Owns a set of statements, identified by Sid, in a bucket policy shared with other stacks. Every apply reads the policy, replaces only the owned statements and retries when another writer changes the policy concurrently. Destroy removes only the owned statements, and the policy itself once it is empty.",
+		targetDescription: "Name of the bucket",
+		regionDescription: "Region of the bucket. Defaults to the provider region",
+		policyDescription: "Policy document holding the statements to own. Every statement needs a Sid that no other statement of the bucket policy uses",
+		newStore:          awscloud.NewBucketPolicyStore,
+	}
+}
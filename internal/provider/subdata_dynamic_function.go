@@ -0,0 +1,129 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	_ function.Function = &SubDataDynamic{}
+)
+
+func SubDataDynamicFunction() function.Function {
+	return &SubDataDynamic{}
+}
+
+type SubDataDynamic struct{}
+
+func (r SubDataDynamic) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "sub_data_dynamic"
+}
+
+func (f *SubDataDynamic) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Replace references in any value, or in YAML or JSON text",
+		Description: "Given any Terraform value, or YAML or JSON text with `format` set, will resolve the references of its strings the way `sub_data` does and return the result as a value, so no `jsonencode` or `jsondecode` is needed.",
+
+		Parameters: []function.Parameter{
+			function.DynamicParameter{
+				Name:        "value",
+				Description: "The value to resolve references in, or YAML or JSON text when format is set",
+			},
+			function.StringParameter{
+				Name:           "format",
+				Description:    "yaml or json to parse value as text, null to use value as is",
+				AllowNullValue: true,
+			},
+			function.BoolParameter{
+				Name:           "strict",
+				Description:    "If true, will throw an error if a reference is not found",
+				AllowNullValue: true,
+			},
+		},
+		Return: function.DynamicReturn{},
+	}
+}
+
+func (f *SubDataDynamic) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var value types.Dynamic
+	var format types.String
+	var strict types.Bool
+
+	resp.Error = req.Arguments.Get(ctx, &value, &format, &strict)
+	if resp.Error != nil {
+		return
+	}
+
+	if !format.IsNull() && format.ValueString() != "yaml" && format.ValueString() != "json" {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Error reading format: unsupported format %q, expected yaml or json", format.ValueString()))
+		return
+	}
+
+	document, err := dynamicToGoType(value)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error reading value: %s", err.Error()))
+		return
+	}
+
+	if !format.IsNull() {
+		text, ok := document.(string)
+		if !ok {
+			resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error reading value: expected %s text when format is set", format.ValueString()))
+			return
+		}
+		document, err = parseDocument(text, format.ValueString())
+		if err != nil {
+			resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error parsing value: %s", err.Error()))
+			return
+		}
+	}
+
+	document, err = resolveDocument(ctx, document, strict.ValueBool())
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error resolving references: %s", err.Error()))
+		return
+	}
+
+	result, diags := decodeAny(ctx, document)
+	if diags.HasError() {
+		resp.Error = function.NewFuncError(fmt.Sprintf("Error converting the resolved value: %s", diags.Errors()[0].Detail()))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, types.DynamicValue(result))
+}
+
+// parseDocument decodes YAML or JSON text into the types dynamicToGoType returns. YAML goes through JSON,
+// so its integers become float64 and its timestamps strings, and keys that are lists or maps are an error.
+func parseDocument(text string, format string) (any, error) {
+	var document any
+	switch format {
+	case "json":
+		if err := json.Unmarshal([]byte(text), &document); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	case "yaml":
+		var parsed any
+		if err := yaml.Unmarshal([]byte(text), &parsed); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		encoded, err := json.Marshal(parsed)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		if err := json.Unmarshal(encoded, &document); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q, expected yaml or json", format)
+	}
+	return document, nil
+}
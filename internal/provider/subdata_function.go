@@ -7,10 +7,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	awscloud "terraform-provider-awsutils/internal/aws_cloud"
 
 	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 var (
@@ -29,8 +31,8 @@ func (r AwsVarFunction) Metadata(_ context.Context, req function.MetadataRequest
 
 func (f *AwsVarFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
 	resp.Definition = function.Definition{
-		Summary:     "Parse an JSON string and replace ssm:: and secret:: references if found",
-		Description: "Given an JSON string, this provider function will parse and return the values of ssm and secret references if found",
+		Summary:     "Parse an JSON string and replace ssm::, secret:: and other references if found",
+		Description: "Given an JSON string, this provider function will parse and return the values of ssm and secret references if found. References are resolved anywhere in the document, including inside arrays, and errors name the JSON pointer of the failing value",
 
 		Parameters: []function.Parameter{
 			function.StringParameter{
@@ -48,116 +50,126 @@ func (f *AwsVarFunction) Definition(ctx context.Context, req function.Definition
 	}
 }
 
-// a function that iterates over each key and value recursively of map[string]interface{} and prints them.
-//
-//nolint:errcheck
-func traverseMap(m map[string]interface{}, strict bool) (map[string]interface{}, error) {
-	for k, v := range m {
-
-		switch valType := v.(type) {
-		case string:
-			fmt.Println(k, v)
-
-			valStr, ok := v.(string)
-			if !ok {
-				if strict {
-					return nil, fmt.Errorf("value for key %s is not a string", k)
-				}
-			}
-			if strings.Contains(valStr, "::") {
-				configVal := awscloud.ParseStr(valStr)
-
-				switch configVal.Service {
-				case "ssm":
-					param, err := awscloud.FetchSSMParameter(configVal.ID, &configVal.Region)
-					if err != nil || param == "" {
-						if strict {
-							return nil, fmt.Errorf("SSM parameter not found")
-						}
-					}
-
-					if param != "" {
-						m[k] = param
-					}
-
-				case "secret":
-					secret, err := awscloud.FetchSecret(configVal.ID, &configVal.Region)
-					if err != nil || secret == "" {
-
-						if strict {
-							return nil, fmt.Errorf("secret not found")
-						}
-					}
-
-					if secret != "" {
-						m[k] = secret
-					}
-
-				default:
-					fmt.Println(k, v)
-
-				}
+// referenceResolver returns the value a string found at the JSON pointer resolves to, and false to keep the string as is.
+type referenceResolver func(pointer string, s string) (any, bool, error)
+
+// walkReferences walks arbitrary decoded JSON, objects, arrays and nested arrays alike, and replaces every
+// string the resolver resolves. pointer is the RFC 6901 JSON pointer of value, used in errors.
+func walkReferences(value any, pointer string, resolve referenceResolver) (any, error) {
+	switch v := value.(type) {
+	case string:
+		resolved, ok, err := resolve(pointer, v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", displayPointer(pointer), err)
+		}
+		if ok {
+			return resolved, nil
+		}
+		return v, nil
+	case map[string]any:
+		for k, e := range v {
+			resolved, err := walkReferences(e, pointer+"/"+escapePointerToken(k), resolve)
+			if err != nil {
+				return nil, err
 			}
-		case map[string]interface{}:
-			fmt.Println(k)
-			vMap, ok := v.(map[string]interface{})
-
-			if !ok {
-				if strict {
-					return nil, fmt.Errorf("value for key %s is not a map", k)
-				}
+			v[k] = resolved
+		}
+		return v, nil
+	case []any:
+		for i, e := range v {
+			resolved, err := walkReferences(e, pointer+"/"+strconv.Itoa(i), resolve)
+			if err != nil {
+				return nil, err
 			}
-			//nolint:errcheck
-			tMap, terr := traverseMap(vMap, strict)
+			v[i] = resolved
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
 
-			if terr != nil || tMap == nil {
-				if strict {
-					return nil, fmt.Errorf("error traversing map: %v", terr)
-				}
-			}
+func escapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
 
-		default:
-			fmt.Println(k, v, valType)
-		}
+// displayPointer names the document root, whose JSON pointer is the empty string.
+func displayPointer(pointer string) string {
+	if pointer == "" {
+		return "/"
 	}
-	return m, nil
+	return pointer
 }
 
-func (f *AwsVarFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
-	var json_file string
-	var strict bool
+// resolveDocument resolves the references of a decoded JSON document. Every reference is collected first
+// so that SSM parameters and secrets are fetched in batches, per region, before the document is walked.
+func resolveDocument(ctx context.Context, document any, strict bool) (any, error) {
+	var refs []awscloud.Reference
+	_, _ = walkReferences(document, "", func(_ string, s string) (any, bool, error) {
+		if template, err := awscloud.ParseTemplate(s); err == nil {
+			refs = append(refs, template.References()...)
+		}
+		return nil, false, nil
+	})
+	awscloud.PrefetchReferences(ctx, refs)
+
+	return walkReferences(document, "", resolveReference(ctx, strict))
+}
+
+// resolveReference resolves service::id::region references with the resolvers registered in awscloud,
+// whole strings or ${...} inside a string, other strings are kept.
+func resolveReference(ctx context.Context, strict bool) referenceResolver {
+	fetch := awscloud.ResolveReferences(ctx)
+	return func(pointer string, s string) (any, bool, error) {
+		if !strings.Contains(s, "::") && !strings.Contains(s, "$${") {
+			return nil, false, nil
+		}
 
-	resp.Error = req.Arguments.Get(ctx, &json_file, &strict)
+		template, err := awscloud.ParseTemplate(s)
+		if err == nil {
+			var value any
+			value, err = template.Render(fetch)
+			if err == nil {
+				return value, true, nil
+			}
+		}
 
-	// parse the JSON file into a map.
-	var jsonMap map[string]interface{}
+		if strict {
+			return nil, false, err
+		}
+		return nil, false, nil
+	}
+}
 
-	err := json.Unmarshal([]byte(json_file), &jsonMap)
+func (f *AwsVarFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var jsonFile string
+	var strict types.Bool
 
-	if err != nil {
-		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error parsing JSON file: %q is not a valid JSON file", json_file))
+	resp.Error = req.Arguments.Get(ctx, &jsonFile, &strict)
+	if resp.Error != nil {
 		return
 	}
 
-	// traverse the map and replace ssm:: and secret:: references if found.
-	//nolint:errcheck
-	_, err = traverseMap(jsonMap, strict)
+	// parse the JSON document, which may be an object, an array or a single value.
+	var document any
+	if err := json.Unmarshal([]byte(jsonFile), &document); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error parsing JSON file: %q is not a valid JSON file", jsonFile))
+		return
+	}
 
+	// replace references wherever they are.
+	document, err := resolveDocument(ctx, document, strict.ValueBool())
 	if err != nil {
-		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error parsing JSON file: %q", json_file))
-		if strict {
-			return
-		}
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error resolving references: %s", err.Error()))
+		return
 	}
 
-	// convert the map back to a JSON string.
-	var json_str []byte
-	json_str, err = json.Marshal(jsonMap)
-
+	// convert the document back to a JSON string.
+	jsonStr, err := json.Marshal(document)
 	if err != nil {
-		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error converting JSON map to string: %q", json_file))
+		resp.Error = function.NewFuncError(fmt.Sprintf("Error converting the resolved document to JSON: %s", err.Error()))
 		return
 	}
 
-	resp.Error = resp.Result.Set(ctx, string(json_str))
+	resp.Error = resp.Result.Set(ctx, string(jsonStr))
 }
@@ -0,0 +1,69 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWalkReferences(t *testing.T) {
+	var document any
+	if err := json.Unmarshal([]byte(`{"a/b": ["x", ["ssm::p"]], "c": {"d~": "ssm::q", "n": 1}}`), &document); err != nil {
+		t.Fatal(err)
+	}
+
+	var pointers []string
+	resolved, err := walkReferences(document, "", func(pointer, s string) (any, bool, error) {
+		if !strings.HasPrefix(s, "ssm::") {
+			return nil, false, nil
+		}
+		pointers = append(pointers, pointer)
+		return strings.ToUpper(s[len("ssm::"):]), true, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{"a/b": []any{"x", []any{"P"}}, "c": map[string]any{"d~": "Q", "n": float64(1)}}
+	if !reflect.DeepEqual(resolved, want) {
+		t.Errorf("resolved %v", resolved)
+	}
+	sort.Strings(pointers)
+	if !reflect.DeepEqual(pointers, []string{"/a~1b/1/0", "/c/d~0"}) {
+		t.Errorf("pointers %v", pointers)
+	}
+
+	_, err = walkReferences([]any{"ok", "bad"}, "", func(_, s string) (any, bool, error) {
+		if s == "bad" {
+			return nil, false, errors.New("not found")
+		}
+		return nil, false, nil
+	})
+	if err == nil || err.Error() != "/1: not found" {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestParseDocument(t *testing.T) {
+	document, err := parseDocument("db:\n  port: 5432\n  hosts: [a, b]\n  password: ${ssm::/db/password}\n", "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"db": map[string]any{"port": float64(5432), "hosts": []any{"a", "b"}, "password": "${ssm::/db/password}"}}
+	if !reflect.DeepEqual(document, want) {
+		t.Errorf("parsed %v", document)
+	}
+
+	if _, err := parseDocument("1: a\n", "yaml"); err != nil {
+		t.Errorf("numeric keys: %v", err)
+	}
+	if _, err := parseDocument("{", "json"); err == nil {
+		t.Error("expected invalid JSON to be rejected")
+	}
+}
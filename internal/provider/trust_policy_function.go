@@ -0,0 +1,261 @@
+// Copyright (c) https://github.com/fd008, all rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	awscloud "terraform-provider-awsutils/internal/aws_cloud"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = &TrustPolicy{}
+)
+
+func TrustPolicyFunction() function.Function {
+	return &TrustPolicy{}
+}
+
+type TrustPolicy struct{}
+
+func (r TrustPolicy) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "trust_policy"
+}
+
+func (f *TrustPolicy) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Return an assume role policy for OIDC providers, services and other accounts",
+		Description: "Given a spec object with `oidc` (GitHub, GitLab or EKS trusts filtered on repositories, branches, tags, environments or service accounts), `services` (service principals) and `cross_account` (accounts or roles, with external IDs or MFA), will return the trust policy JSON string with the matching aud and sub conditions.",
+
+		Parameters: []function.Parameter{
+			function.DynamicParameter{
+				Name:        "spec",
+				Description: "The trust spec object. oidc and cross_account take an object or a list of objects, services a string or a list of strings",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *TrustPolicy) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var specArg types.Dynamic
+
+	resp.Error = req.Arguments.Get(ctx, &specArg)
+	if resp.Error != nil {
+		return
+	}
+
+	value, err := dynamicToGoType(specArg)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error reading spec: %s", err.Error()))
+		return
+	}
+
+	spec, err := trustSpecFromMap(value)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Error reading spec: %s", err.Error()))
+		return
+	}
+
+	policy, err := awscloud.TrustPolicy(spec)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid spec: %s", err.Error()))
+		return
+	}
+
+	policyJSON, err := json.Marshal(policy)
+	if err != nil {
+		resp.Error = function.NewFuncError(fmt.Sprintf("Error marshalling policy: %s", err.Error()))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, string(policyJSON))
+}
+
+// trustSpecFromMap reads the trust_policy spec object.
+func trustSpecFromMap(value any) (awscloud.TrustSpec, error) {
+	var spec awscloud.TrustSpec
+
+	m, ok := value.(map[string]any)
+	if !ok {
+		return spec, fmt.Errorf("spec must be an object")
+	}
+
+	for k, v := range m {
+		if v == nil {
+			continue
+		}
+
+		var err error
+		switch k {
+		case "partition":
+			spec.Partition, err = stringOption(k, v)
+		case "services":
+			spec.Services, err = stringsOption(k, v)
+		case "oidc":
+			var objects []map[string]any
+			objects, err = objectsOption(k, v)
+			for i, o := range objects {
+				var trust awscloud.OIDCTrust
+				trust, err = oidcTrustFromMap(o)
+				if err != nil {
+					return spec, fmt.Errorf("oidc %d: %w", i+1, err)
+				}
+				spec.OIDC = append(spec.OIDC, trust)
+			}
+		case "cross_account":
+			var objects []map[string]any
+			objects, err = objectsOption(k, v)
+			for i, o := range objects {
+				var trust awscloud.CrossAccountTrust
+				trust, err = crossAccountTrustFromMap(o)
+				if err != nil {
+					return spec, fmt.Errorf("cross_account %d: %w", i+1, err)
+				}
+				spec.CrossAccount = append(spec.CrossAccount, trust)
+			}
+		default:
+			err = fmt.Errorf("unsupported attribute %q", k)
+		}
+		if err != nil {
+			return spec, err
+		}
+	}
+
+	return spec, nil
+}
+
+func oidcTrustFromMap(m map[string]any) (awscloud.OIDCTrust, error) {
+	var trust awscloud.OIDCTrust
+
+	for k, v := range m {
+		if v == nil {
+			continue
+		}
+
+		var err error
+		switch k {
+		case "provider":
+			trust.Provider, err = stringOption(k, v)
+		case "account":
+			trust.Account, err = stringOption(k, v)
+		case "provider_arn":
+			trust.ProviderARN, err = stringOption(k, v)
+		case "audience":
+			trust.Audience, err = stringOption(k, v)
+		case "repositories":
+			trust.Repositories, err = stringsOption(k, v)
+		case "projects":
+			trust.Projects, err = stringsOption(k, v)
+		case "host":
+			trust.Host, err = stringOption(k, v)
+		case "branches":
+			trust.Branches, err = stringsOption(k, v)
+		case "tags":
+			trust.Tags, err = stringsOption(k, v)
+		case "environments":
+			trust.Environments, err = stringsOption(k, v)
+		case "pull_request":
+			var ok bool
+			if trust.PullRequest, ok = v.(bool); !ok {
+				err = fmt.Errorf("%s must be a bool", k)
+			}
+		case "issuer":
+			trust.Issuer, err = stringOption(k, v)
+		case "namespace":
+			trust.Namespace, err = stringOption(k, v)
+		case "service_accounts":
+			trust.ServiceAccounts, err = stringsOption(k, v)
+		default:
+			err = fmt.Errorf("unsupported attribute %q", k)
+		}
+		if err != nil {
+			return trust, err
+		}
+	}
+
+	return trust, nil
+}
+
+func crossAccountTrustFromMap(m map[string]any) (awscloud.CrossAccountTrust, error) {
+	var trust awscloud.CrossAccountTrust
+
+	for k, v := range m {
+		if v == nil {
+			continue
+		}
+
+		var err error
+		switch k {
+		case "principals":
+			trust.Principals, err = stringsOption(k, v)
+		case "external_ids":
+			trust.ExternalIDs, err = stringsOption(k, v)
+		case "require_mfa":
+			var ok bool
+			if trust.RequireMFA, ok = v.(bool); !ok {
+				err = fmt.Errorf("%s must be a bool", k)
+			}
+		default:
+			err = fmt.Errorf("unsupported attribute %q", k)
+		}
+		if err != nil {
+			return trust, err
+		}
+	}
+
+	return trust, nil
+}
+
+func stringOption(name string, v any) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", name)
+	}
+	return s, nil
+}
+
+// stringsOption reads a string or a list of strings.
+func stringsOption(name string, v any) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for i, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s element %d must be a string", name, i+1)
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("%s must be a string or a list of strings", name)
+	}
+}
+
+// objectsOption reads an object or a list of objects.
+func objectsOption(name string, v any) ([]map[string]any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		return []map[string]any{v}, nil
+	case []any:
+		objects := make([]map[string]any, 0, len(v))
+		for i, e := range v {
+			o, ok := e.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s element %d must be an object", name, i+1)
+			}
+			objects = append(objects, o)
+		}
+		return objects, nil
+	default:
+		return nil, fmt.Errorf("%s must be an object or a list of objects", name)
+	}
+}
@@ -25,6 +25,12 @@ func decodeAny(ctx context.Context, m any) (value attr.Value, diags diag.Diagnos
 		value = types.BoolValue(v)
 	case []any:
 		return decodeList(ctx, v)
+	case []string:
+		list := make([]any, len(v))
+		for i, e := range v {
+			list[i] = e
+		}
+		return decodeList(ctx, list)
 	case map[string]any:
 		return decodeMap(ctx, v)
 	default:
@@ -82,7 +88,7 @@ func dynamicToGoType(d types.Dynamic) (any, error) {
 	case types.Tuple:
 		var list []any
 		for _, e := range v.Elements() {
-			ev, err := dynamicToGoType(e.(types.Dynamic))
+			ev, err := dynamicToGoType(types.DynamicValue(e))
 			if err != nil {
 				return nil, err
 			}
@@ -92,7 +98,7 @@ func dynamicToGoType(d types.Dynamic) (any, error) {
 	case types.Object:
 		m := make(map[string]any)
 		for k, e := range v.Attributes() {
-			ev, err := dynamicToGoType(e.(types.Dynamic))
+			ev, err := dynamicToGoType(types.DynamicValue(e))
 			if err != nil {
 				return nil, err
 			}
@@ -102,7 +108,7 @@ func dynamicToGoType(d types.Dynamic) (any, error) {
 	case types.Map:
 		m := make(map[string]any)
 		for k, e := range v.Elements() {
-			ev, err := dynamicToGoType(e.(types.Dynamic))
+			ev, err := dynamicToGoType(types.DynamicValue(e))
 			if err != nil {
 				return nil, err
 			}
@@ -112,7 +118,7 @@ func dynamicToGoType(d types.Dynamic) (any, error) {
 	case types.List:
 		var list []any
 		for _, e := range v.Elements() {
-			ev, err := dynamicToGoType(e.(types.Dynamic))
+			ev, err := dynamicToGoType(types.DynamicValue(e))
 			if err != nil {
 				return nil, err
 			}
@@ -124,7 +130,7 @@ func dynamicToGoType(d types.Dynamic) (any, error) {
 	case types.Set:
 		var list []any
 		for _, e := range v.Elements() {
-			ev, err := dynamicToGoType(e.(types.Dynamic))
+			ev, err := dynamicToGoType(types.DynamicValue(e))
 			if err != nil {
 				return nil, err
 			}